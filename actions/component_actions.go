@@ -1,92 +1,200 @@
 package actions
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/ensi-platform/elc/core"
+	"strings"
+	"text/tabwriter"
 )
 
 func resolveCompNames(ws *core.Workspace, options *core.GlobalOptions, namesFromArgs []string) ([]string, error) {
-	var compNames []string
+	var selectors []string
+	if options.ComponentName != "" {
+		selectors = append(selectors, options.ComponentName)
+	}
+	selectors = append(selectors, namesFromArgs...)
 
-	if options.Tag != "" {
-		compNames = ws.FindComponentNamesByTag(options.Tag)
-		if len(compNames) == 0 {
-			return nil, errors.New(fmt.Sprintf("components with tag %s not found", options.Tag))
+	if len(selectors) == 0 {
+		if options.AllWorkspaces && options.Filter.IsEmpty() {
+			return nil, errors.New("select components explicitly with names, selectors or filters, current component is not detected with --all-workspaces")
 		}
-	} else if options.ComponentName != "" {
-		compNames = []string{options.ComponentName}
-	} else if len(namesFromArgs) > 0 {
-		compNames = namesFromArgs
-	} else {
+		if !options.Filter.IsEmpty() {
+			return ws.FindComponentNamesOrError(&options.Filter, options)
+		}
+
 		currentCompName, err := ws.ComponentNameByPath()
 		if err != nil {
 			return nil, err
 		}
-		compNames = []string{currentCompName}
+		return []string{currentCompName}, nil
 	}
 
-	return compNames, nil
-}
-
-func ListCompNames(ws *core.Workspace, options *core.GlobalOptions) ([]string, error) {
-	var compNames []string
-	if options.Tag == "" {
-		return ws.GetComponentNamesList(), nil
+	compNames, err := ws.SelectComponentNames(selectors, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Filter.IsEmpty() {
+		return compNames, nil
 	}
 
-	compNames = ws.FindComponentNamesByTag(options.Tag)
+	compNames, err = ws.FilterComponentNames(compNames, &options.Filter, options)
+	if err != nil {
+		return nil, err
+	}
 	if len(compNames) == 0 {
-		return nil, errors.New(fmt.Sprintf("components with tag %s not found", options.Tag))
+		return nil, errors.New(fmt.Sprintf("components with %s not found", &options.Filter))
 	}
 
 	return compNames, nil
 }
 
-func StartServiceAction(options *core.GlobalOptions, svcNames []string) error {
-	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
-	if err != nil {
-		return err
+// forEachWorkspace calls action for workspace of command, with --all-workspaces it is called for every registered workspace.
+// Failure in one workspace does not stop the action in others.
+func forEachWorkspace(options *core.GlobalOptions, action func(ws *core.Workspace) error) error {
+	if !options.AllWorkspaces {
+		ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
+		if err != nil {
+			return err
+		}
+		return action(ws)
 	}
 
-	compNames, err := resolveCompNames(ws, options, svcNames)
+	if options.WorkspaceName != "" {
+		return errors.New("--workspace can not be used with --all-workspaces")
+	}
+
+	workspaces, err := core.LoadRegisteredWorkspaces()
 	if err != nil {
 		return err
 	}
 
-	for _, compName := range compNames {
-		fmt.Printf("# component: %s\n", compName)
-		comp, err := ws.ComponentByName(compName)
-		if err != nil {
-			return err
+	failed := 0
+	for _, rw := range workspaces {
+		fmt.Printf("# workspace: %s\n", rw.Name)
+		err = rw.Error
+		if err == nil {
+			err = action(rw.Workspace)
 		}
-
-		err = comp.Start(options)
 		if err != nil {
 			fmt.Printf("Error: %s\n", err)
+			failed++
 		}
 	}
 
+	if failed > 0 {
+		return errors.New(fmt.Sprintf("command failed in %d of %d workspaces", failed, len(workspaces)))
+	}
+
 	return nil
 }
 
-func StopServiceAction(stopAll bool, svcNames []string, destroy bool, options *core.GlobalOptions) error {
-	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
-	if err != nil {
-		return err
+func ListCompNames(ws *core.Workspace, options *core.GlobalOptions) ([]string, error) {
+	if options.Filter.IsEmpty() {
+		return ws.FindComponentNames(&options.Filter, options)
 	}
 
-	var compNames []string
+	return ws.FindComponentNamesOrError(&options.Filter, options)
+}
 
-	if stopAll {
-		compNames = ws.GetComponentNames()
-	} else {
-		compNames, err = resolveCompNames(ws, options, svcNames)
+func StartServiceAction(options *core.GlobalOptions, svcNames []string) error {
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		compNames, err := resolveCompNames(ws, options, svcNames)
 		if err != nil {
 			return err
 		}
-	}
 
+		for _, compName := range compNames {
+			fmt.Printf("# component: %s\n", compName)
+			comp, err := ws.ComponentByName(compName)
+			if err != nil {
+				return err
+			}
+
+			err = comp.Start(options)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func StopServiceAction(stopAll bool, svcNames []string, destroy bool, options *core.GlobalOptions) error {
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		var compNames []string
+		var err error
+
+		if stopAll {
+			compNames = ws.GetComponentNames()
+		} else {
+			compNames, err = resolveCompNames(ws, options, svcNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		return stopComponents(ws, compNames, destroy, options)
+	})
+}
+
+// PruneAction removes containers of compose projects of services, including orphans, and optionally their volumes,
+// images and networks. Resources are listed and confirmed by user before removal, with --dry-run they are only listed.
+func PruneAction(pruneAll bool, yes bool, svcNames []string, pruneOptions core.PruneOptions, options *core.GlobalOptions) error {
+	pruneOptions.WorkspaceNetworks = pruneAll && pruneOptions.Networks
+
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		var compNames []string
+		var err error
+
+		if pruneAll {
+			compNames = ws.GetComponentNames()
+		} else {
+			compNames, err = resolveCompNames(ws, options, svcNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		resources, err := ws.PrunePlan(compNames, pruneOptions)
+		if err != nil {
+			return err
+		}
+		if len(resources) == 0 {
+			_, _ = core.Pc.Println("nothing to prune")
+			return nil
+		}
+
+		for _, resource := range resources {
+			_, _ = core.Pc.Printf("%-9s %s\n", resource.Kind, resource.Name)
+		}
+		if options.DryRun {
+			return nil
+		}
+
+		if !yes {
+			if !core.Pc.IsStdinTerminal() {
+				return errors.New("confirmation is required, run with --yes to prune without prompt")
+			}
+			_, _ = core.Pc.Printf("Remove %d resources? [y/N] ", len(resources))
+			answer, err := core.Pc.ReadLine()
+			if err != nil {
+				return err
+			}
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				_, _ = core.Pc.Println("cancelled")
+				return nil
+			}
+		}
+
+		return ws.Prune(resources, options)
+	})
+}
+
+func stopComponents(ws *core.Workspace, compNames []string, destroy bool, options *core.GlobalOptions) error {
 	for _, compName := range compNames {
 		fmt.Printf("# component: %s\n", compName)
 		comp, err := ws.ComponentByName(compName)
@@ -107,33 +215,30 @@ func StopServiceAction(stopAll bool, svcNames []string, destroy bool, options *c
 }
 
 func RestartServiceAction(hardRestart bool, svcNames []string, options *core.GlobalOptions) error {
-	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
-	if err != nil {
-		return err
-	}
-
-	compNames, err := resolveCompNames(ws, options, svcNames)
-	if err != nil {
-		return err
-	}
-
-	for _, compName := range compNames {
-		fmt.Printf("# component: %s\n", compName)
-		comp, err := ws.ComponentByName(compName)
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		compNames, err := resolveCompNames(ws, options, svcNames)
 		if err != nil {
 			return err
 		}
 
-		err = comp.Restart(hardRestart, options)
-		if err != nil {
-			fmt.Printf("Error: %s\n", err)
+		for _, compName := range compNames {
+			fmt.Printf("# component: %s\n", compName)
+			comp, err := ws.ComponentByName(compName)
+			if err != nil {
+				return err
+			}
+
+			err = comp.Restart(hardRestart, options)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
-func PrintVarsAction(options *core.GlobalOptions, svcNames []string) error {
+func PrintVarsAction(options *core.GlobalOptions, svcNames []string, exportDotenv bool) error {
 	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
 	if err != nil {
 		return err
@@ -153,12 +258,13 @@ func PrintVarsAction(options *core.GlobalOptions, svcNames []string) error {
 		return err
 	}
 
-	err = comp.DumpVars()
-	if err != nil {
-		return err
+	if exportDotenv {
+		return comp.ExportDotenv()
 	}
 
-	return nil
+	return core.PrintOutput(options.Output, comp.VariablesInfo(), func() {
+		_ = comp.DumpVars()
+	})
 }
 
 func ComposeCommandAction(options *core.GlobalOptions, args []string) error {
@@ -328,7 +434,7 @@ func RunAction(options *core.GlobalOptions) error {
 	return nil
 }
 
-func SetGitHooksAction(options *core.GlobalOptions, scriptsFolder string, elcBinary string) error {
+func SetGitHooksAction(options *core.GlobalOptions, scriptsFolder string, elcBinary string, uninstall bool, check bool) error {
 	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
 	if err != nil {
 		return err
@@ -339,6 +445,7 @@ func SetGitHooksAction(options *core.GlobalOptions, scriptsFolder string, elcBin
 		return err
 	}
 
+	outdated := false
 	for _, compName := range compNames {
 		fmt.Printf("# component: %s\n", compName)
 		comp, err := ws.ComponentByName(compName)
@@ -346,43 +453,109 @@ func SetGitHooksAction(options *core.GlobalOptions, scriptsFolder string, elcBin
 			return err
 		}
 
-		err = comp.UpdateHooks(options, elcBinary, scriptsFolder)
+		if check {
+			problems, err := comp.CheckHooks(elcBinary, scriptsFolder)
+			if err != nil {
+				return err
+			}
+			for _, problem := range problems {
+				fmt.Printf("%s\n", problem)
+			}
+			if len(problems) > 0 {
+				outdated = true
+			}
+			continue
+		}
+
+		if uninstall {
+			err = comp.RemoveHooks(options)
+		} else {
+			err = comp.UpdateHooks(options, elcBinary, scriptsFolder)
+		}
 		if err != nil {
 			fmt.Printf("Error: %s\n", err)
 		}
 	}
 
+	if outdated {
+		return errors.New("hooks are outdated, run 'elc set-hooks' to update them")
+	}
+
 	return nil
 }
 
-func CloneComponentAction(options *core.GlobalOptions, svcNames []string, noHook bool) error {
+func RunHookAction(options *core.GlobalOptions, hooksFolder string, hookName string, hookArgs []string) error {
 	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
 	if err != nil {
 		return err
 	}
 
-	compNames, err := resolveCompNames(ws, options, svcNames)
+	compNames, err := resolveCompNames(ws, options, []string{})
 	if err != nil {
 		return err
 	}
 
-	for _, compName := range compNames {
-		fmt.Printf("# component: %s\n", compName)
-		comp, err := ws.ComponentByName(compName)
+	if len(compNames) > 1 {
+		return errors.New("too many components")
+	}
+
+	comp, err := ws.ComponentByName(compNames[0])
+	if err != nil {
+		return err
+	}
+
+	return comp.RunHook(options, hooksFolder, hookName, hookArgs)
+}
+
+func CloneComponentAction(options *core.GlobalOptions, svcNames []string, noHook bool) error {
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		compNames, err := resolveCompNames(ws, options, svcNames)
 		if err != nil {
 			return err
 		}
 
-		err = comp.Clone(options, noHook)
+		for _, compName := range compNames {
+			fmt.Printf("# component: %s\n", compName)
+			comp, err := ws.ComponentByName(compName)
+			if err != nil {
+				return err
+			}
+
+			err = comp.Clone(options, noHook)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func PullComponentAction(options *core.GlobalOptions, svcNames []string, noHook bool) error {
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		compNames, err := resolveCompNames(ws, options, svcNames)
 		if err != nil {
-			fmt.Printf("Error: %s\n", err)
+			return err
 		}
-	}
 
-	return nil
+		for _, compName := range compNames {
+			fmt.Printf("# component: %s\n", compName)
+			comp, err := ws.ComponentByName(compName)
+			if err != nil {
+				return err
+			}
+
+			err = comp.Pull(options, noHook)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err)
+			}
+		}
+
+		return nil
+	})
 }
 
-func ListServicesAction(options *core.GlobalOptions) error {
+func ListServicesAction(options *core.GlobalOptions, quiet bool) error {
 	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
 	if err != nil {
 		return err
@@ -393,9 +566,69 @@ func ListServicesAction(options *core.GlobalOptions) error {
 		return err
 	}
 
+	if quiet {
+		for _, compName := range compNames {
+			_, _ = core.Pc.Println(compName)
+		}
+		return nil
+	}
+
+	// list works without container runtime, running state is unknown then
+	states, runtimeAvailable, err := ws.ComponentStatesIfAvailable(compNames, options)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]core.ComponentInfo, 0)
 	for _, compName := range compNames {
-		_, _ = core.Pc.Println(compName)
+		comp, err := ws.ComponentByName(compName)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, comp.Info(states[compName]))
 	}
 
-	return nil
+	return core.PrintOutput(options.Output, infos, func() {
+		printComponentsTable(infos, runtimeAvailable)
+	})
+}
+
+func printComponentsTable(infos []core.ComponentInfo, runtimeAvailable bool) {
+	valueOrDash := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+	yesNo := func(value bool) string {
+		if value {
+			return "yes"
+		}
+		return "no"
+	}
+
+	running := func(value bool) string {
+		if !runtimeAvailable {
+			return "-"
+		}
+		return yesNo(value)
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tTYPE\tEXTENDS\tHOSTED_IN\tTAGS\tCLONED\tRUNNING")
+	for _, info := range infos {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.Name,
+			info.Type,
+			valueOrDash(info.Template),
+			valueOrDash(info.HostedIn),
+			valueOrDash(strings.Join(info.Tags, ",")),
+			yesNo(info.Cloned),
+			running(info.Running),
+		)
+	}
+	_ = w.Flush()
+
+	_, _ = core.Pc.Printf("%s", buf.String())
 }
@@ -1,10 +1,15 @@
 package actions
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ensi-platform/elc/core"
 	"github.com/golang/mock/gomock"
+	"os"
 	"path"
+	"runtime"
 	"testing"
+	"time"
 )
 
 const fakeHomeConfigPath = "/tmp/home/.elc.yaml"
@@ -30,16 +35,81 @@ func setupMockPc(t *testing.T) *core.MockPC {
 }
 
 func expectReadHomeConfig(mockPC *core.MockPC) {
+	expectReadCustomHomeConfig(mockPC, baseHomeConfig)
+}
+
+func expectReadCustomHomeConfig(mockPC *core.MockPC, config string) {
 	mockPC.EXPECT().HomeDir().Return("/tmp/home", nil)
 	mockPC.EXPECT().FileExists(fakeHomeConfigPath).Return(true)
-	mockPC.EXPECT().ReadFile(fakeHomeConfigPath).Return([]byte(baseHomeConfig), nil)
+	mockPC.EXPECT().ReadFile(fakeHomeConfigPath).Return([]byte(config), nil)
+}
+
+func expectNoLocalWorkspace(mockPC *core.MockPC, cwd string) {
+	for dir := cwd; ; dir = path.Dir(dir) {
+		mockPC.EXPECT().FileExists(path.Join(dir, "workspace.yaml")).Return(false).AnyTimes()
+		mockPC.EXPECT().FileExists(path.Join(dir, ".elc")).Return(false).AnyTimes()
+		if dir == "/" {
+			break
+		}
+	}
 }
 
 func expectReadWorkspaceConfig(mockPC *core.MockPC, workspacePath string, config string, env string) {
+	expectReadWorkspaceConfigInDir(mockPC, workspacePath, path.Join(workspacePath, "apps/test"), config, env)
+}
+
+func expectReadWorkspaceConfigInDir(mockPC *core.MockPC, workspacePath string, cwd string, config string, env string) {
+	mockPC.EXPECT().Getwd().
+		Return(cwd, nil)
+	expectNoLocalWorkspace(mockPC, cwd)
+	expectWorkspaceFiles(mockPC, workspacePath, config, env)
+}
+
+// expectHostVariablesPrinted expects built-in variables of host user from expectHostUser in output of vars
+func expectHostVariablesPrinted(mockPc *core.MockPC) {
+	mockPc.EXPECT().Println("USER_ID=1000")
+	mockPc.EXPECT().Println("GROUP_ID=1000")
+	mockPc.EXPECT().Println("USER_NAME=user")
+	mockPc.EXPECT().Println("HOME=/tmp/home")
+	mockPc.EXPECT().Println("HOST_OS=" + runtime.GOOS)
+	mockPc.EXPECT().Println("HOST_ARCH=" + runtime.GOARCH)
+}
+
+// hostVariablesYaml is a part of yaml output of vars with built-in variables from expectHostUser
+func hostVariablesYaml(names ...string) string {
+	values := map[string]string{
+		"USER_ID":   `"1000"`,
+		"GROUP_ID":  `"1000"`,
+		"USER_NAME": "user",
+		"HOME":      "/tmp/home",
+		"HOST_OS":   runtime.GOOS,
+		"HOST_ARCH": runtime.GOARCH,
+	}
+	result := ""
+	for _, name := range names {
+		result += fmt.Sprintf("- name: %s\n  value: %s\n", name, values[name])
+	}
+
+	return result
+}
+
+// expectHostUser expects detection of host user for built-in variables of workspace
+func expectHostUser(mockPC *core.MockPC) {
+	mockPC.EXPECT().Getuid().Return(1000)
+	mockPC.EXPECT().Getgid().Return(1000)
+	mockPC.EXPECT().Username().Return("user", nil)
+	mockPC.EXPECT().HomeDir().Return("/tmp/home", nil)
+}
+
+func expectWorkspaceFiles(mockPC *core.MockPC, workspacePath string, config string, env string) {
+	expectWorkspaceConfigFiles(mockPC, workspacePath, config, env)
+	expectHostUser(mockPC)
+}
+
+// expectWorkspaceConfigFiles expects reading of workspace.yaml and env.yaml without creation of context of workspace
+func expectWorkspaceConfigFiles(mockPC *core.MockPC, workspacePath string, config string, env string) {
 	configPath := path.Join(workspacePath, "workspace.yaml")
 	envPath := path.Join(workspacePath, "env.yaml")
-	mockPC.EXPECT().Getwd().
-		Return(path.Join(workspacePath, "apps/test"), nil)
 	mockPC.EXPECT().ReadFile(configPath).
 		Return([]byte(config), nil)
 
@@ -81,6 +151,136 @@ func TestServiceStart(t *testing.T) {
 	_ = StartServiceAction(&core.GlobalOptions{}, []string{})
 }
 
+const workspaceConfigWithNetworks = `
+name: ensi
+networks:
+  ensi:
+    driver: bridge
+    subnet: 172.30.0.0/16
+  ${WORKSPACE_NAME}-shared:
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceStartCreatesNetworks(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+
+	mockPc.EXPECT().
+		FileExists(gomock.Any()).
+		Return(true)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, "", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "bridge\nhost\nensi-shared\n", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "create", "--driver", "bridge", "--subnet", "172.30.0.0/16", "--label", "elc.workspace=ensi", "ensi"}, gomock.Any()).
+		Return(0, "", nil)
+
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "up", "-d"}, gomock.Any()).
+		Return(0, nil)
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartCleanEnv(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+
+	// only compose commands of component get clean environment, query of networks is internal
+	mockPc.EXPECT().WithCleanEnv().Return(mockPc).Times(2)
+
+	mockPc.EXPECT().
+		FileExists(gomock.Any()).
+		Return(true)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, "", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi\nensi-shared\n", nil)
+
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "up", "-d"}, gomock.Any()).
+		Return(0, nil)
+
+	err := StartServiceAction(&core.GlobalOptions{CleanEnv: true}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartNetworksDryRun(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+
+	mockPc.EXPECT().
+		FileExists(gomock.Any()).
+		Return(true)
+
+	// nothing is executed, networks are not listed and all of them are printed as created
+	gomock.InOrder(
+		mockPc.EXPECT().Printf(">> %s\n", "docker compose -f "+composeFilePath+" ps --status=running -q"),
+		mockPc.EXPECT().Printf(">> %s\n", "docker network ls --format {{.Name}}"),
+		mockPc.EXPECT().Printf(">> %s\n", "docker network create --label elc.workspace=ensi ensi-shared"),
+		mockPc.EXPECT().Printf(">> %s\n", "docker network create --driver bridge --subnet 172.30.0.0/16 --label elc.workspace=ensi ensi"),
+		mockPc.EXPECT().Printf(">> %s\n", "docker compose -f "+composeFilePath+" up -d"),
+	)
+
+	err := StartServiceAction(&core.GlobalOptions{Debug: true, DryRun: true}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartNetworkError(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+
+	mockPc.EXPECT().
+		FileExists(gomock.Any()).
+		Return(true)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, "", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-shared\n", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "create", "--driver", "bridge", "--subnet", "172.30.0.0/16", "--label", "elc.workspace=ensi", "ensi"}, gomock.Any()).
+		Return(1, "", errors.New("exit status 1"))
+
+	// compose up must not be called when network is not created
+	_ = StartServiceAction(&core.GlobalOptions{}, []string{})
+}
+
 const workspaceConfigWithDeps = `name: ensi
 variables:
   USER_ID: "1000"
@@ -312,6 +512,20 @@ func TestServiceRestartHard(t *testing.T) {
 	_ = RestartServiceAction(true, []string{}, &core.GlobalOptions{})
 }
 
+func TestServiceRestartDryRun(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	// options of restart are passed to start, so nothing is executed
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/test")).Return(true).Times(2)
+
+	err := RestartServiceAction(false, []string{}, &core.GlobalOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestServiceCompose(t *testing.T) {
 	mockPc := setupMockPc(t)
 	expectReadHomeConfig(mockPc)
@@ -351,6 +565,8 @@ func TestServiceExec(t *testing.T) {
 	expectReadHomeConfig(mockPc)
 	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
 
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep1/docker-compose.yml"))
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep2/docker-compose.yml"))
 	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
 	mockPc.EXPECT().
 		IsTerminal().
@@ -370,6 +586,8 @@ func TestServiceExecWithoutTty(t *testing.T) {
 	expectReadHomeConfig(mockPc)
 	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
 
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep1/docker-compose.yml"))
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep2/docker-compose.yml"))
 	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
 	mockPc.EXPECT().
 		IsTerminal().
@@ -389,6 +607,8 @@ func TestServiceExecWithUid(t *testing.T) {
 	expectReadHomeConfig(mockPc)
 	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
 
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep1/docker-compose.yml"))
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep2/docker-compose.yml"))
 	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
 	mockPc.EXPECT().
 		IsTerminal().
@@ -403,6 +623,59 @@ func TestServiceExecWithUid(t *testing.T) {
 	})
 }
 
+const workspaceConfigWithUserOverride = `
+name: ensi
+variables:
+  USER_ID: 2000
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceExecHostUser(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithUserOverride, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
+	mockPc.EXPECT().
+		IsTerminal().
+		Return(true)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"), "exec", "-u", "2000:1000", "app", "some", "command"}, gomock.Any()).
+		Return(0, nil)
+
+	err := ExecAction(&core.GlobalOptions{
+		Cmd: []string{"some", "command"},
+		UID: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceExecWithoutGroup(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	mockPc.EXPECT().Getwd().Return(path.Join(fakeWorkspacePath, "apps/test"), nil)
+	expectNoLocalWorkspace(mockPc, path.Join(fakeWorkspacePath, "apps/test"))
+	expectWorkspaceConfigFiles(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	mockPc.EXPECT().Getuid().Return(1000)
+	mockPc.EXPECT().Getgid().Return(-1)
+	mockPc.EXPECT().Username().Return("", errors.New("unknown user"))
+	mockPc.EXPECT().HomeDir().Return("", nil)
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
+
+	err := ExecAction(&core.GlobalOptions{
+		Cmd: []string{"some", "command"},
+		UID: -1,
+	})
+	if err == nil || err.Error() != "variable GROUP_ID is not defined" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestServiceRun(t *testing.T) {
 	mockPc := setupMockPc(t)
 	expectReadHomeConfig(mockPc)
@@ -457,6 +730,7 @@ func TestServiceVars(t *testing.T) {
 
 	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
 	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
 
 	mockPc.EXPECT().Println("V_GL=vglobal")
 	mockPc.EXPECT().Println("V_GL_SIMPLE_VAR=vglobal-a")
@@ -470,7 +744,7 @@ func TestServiceVars(t *testing.T) {
 
 	mockPc.EXPECT().Println("V_IN_SVC=vinsvc")
 
-	_ = PrintVarsAction(&core.GlobalOptions{}, []string{})
+	_ = PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
 }
 
 func TestServiceVarsWithTpl(t *testing.T) {
@@ -480,6 +754,7 @@ func TestServiceVarsWithTpl(t *testing.T) {
 
 	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
 	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
 
 	mockPc.EXPECT().Println("V_GL=vglobal")
 	mockPc.EXPECT().Println("V_GL_SIMPLE_VAR=vglobal-a")
@@ -496,5 +771,1511 @@ func TestServiceVarsWithTpl(t *testing.T) {
 
 	mockPc.EXPECT().Println("V_IN_SVC=vinsvc")
 
-	_ = PrintVarsAction(&core.GlobalOptions{}, []string{"test1"})
+	_ = PrintVarsAction(&core.GlobalOptions{}, []string{"test1"}, false)
+}
+
+const workspaceConfigWithSecrets = `
+name: ensi
+secrets:
+  - type: dotenv
+    path: ${WORKSPACE_PATH}/.secrets.env
+variables:
+  DB_PASSWORD: ${secret:DB_PASSWORD}
+  DB_DSN: pgsql://user:${secret:DB_PASSWORD}@db
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceVarsWithSecrets(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithSecrets, "")
+
+	secretsPath := path.Join(fakeWorkspacePath, ".secrets.env")
+	mockPc.EXPECT().FileExists(secretsPath).Return(true)
+	mockPc.EXPECT().ReadFile(secretsPath).Return([]byte("# local secrets\nDB_PASSWORD=\"p4$$w0rd\"\n"), nil)
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("DB_PASSWORD=******")
+	mockPc.EXPECT().Println("DB_DSN=pgsql://user:******@db")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/tmp/workspaces/project1/apps/test")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/apps/test/docker-compose.yml")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const workspaceConfigWithEnvFiles = `
+name: ensi
+env_files:
+  - .env
+variables:
+  DB_HOST: ${DB_HOST:-localhost}
+  DB_NAME: app
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+    env_files:
+      - ${SVC_PATH}/.env
+`
+
+func expectReadEnvFiles(mockPc *core.MockPC) {
+	wsEnvPath := path.Join(fakeWorkspacePath, ".env")
+	mockPc.EXPECT().FileExists(wsEnvPath).Return(true)
+	mockPc.EXPECT().ReadFile(wsEnvPath).Return([]byte("DB_HOST=db\nDB_NAME=from_env\n"), nil)
+
+	svcEnvPath := path.Join(fakeWorkspacePath, "apps/test/.env")
+	mockPc.EXPECT().FileExists(svcEnvPath).Return(true)
+	mockPc.EXPECT().ReadFile(svcEnvPath).Return([]byte("APP_DEBUG=true\nexport APP_TITLE='My App'\n"), nil)
+}
+
+func TestServiceVarsWithEnvFiles(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithEnvFiles, "")
+	expectReadEnvFiles(mockPc)
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("DB_HOST=db")
+	mockPc.EXPECT().Println("DB_NAME=app")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/tmp/workspaces/project1/apps/test")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/apps/test/docker-compose.yml")
+	mockPc.EXPECT().Println("APP_DEBUG=true")
+	mockPc.EXPECT().Println("APP_TITLE=My App")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceVarsExportDotenv(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithEnvFiles, "")
+	expectReadEnvFiles(mockPc)
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("DB_HOST=db")
+	mockPc.EXPECT().Println("DB_NAME=app")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/tmp/workspaces/project1/apps/test")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/apps/test/docker-compose.yml")
+	mockPc.EXPECT().Println("APP_DEBUG=true")
+	mockPc.EXPECT().Println("APP_TITLE='My App'")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const workspaceConfigWithExpressions = `
+name: ensi
+variables:
+  EMPTY: ""
+  APP: Catalog-Api
+  BRACELESS: $APP/$$HOME
+  NESTED_DEFAULT: ${UNDEFINED:-${EMPTY:-${APP}-x}}
+  UNSET_ONLY: ${EMPTY-not-used}
+  ALT: ${APP:+with-app}
+  ALT_EMPTY: ${EMPTY:+not-used}
+  BRACES_IN_DEFAULT: '${UNDEFINED:-{"a": 1}}'
+  ESCAPED_BRACE: ${UNDEFINED:-a\}b}
+  ESCAPED: $${APP}
+  LOWER: ${lower($APP)}
+  UPPER_REPLACED: ${upper(${replace($APP, "-", "_")})}
+  DEFAULT_FN: ${default($UNDEFINED, $EMPTY, fallback)}
+  REQUIRED: ${APP:?app is required}
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceVarsExpressions(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithExpressions, "")
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("EMPTY=")
+	mockPc.EXPECT().Println("APP=Catalog-Api")
+	mockPc.EXPECT().Println("BRACELESS=Catalog-Api/$HOME")
+	mockPc.EXPECT().Println("NESTED_DEFAULT=Catalog-Api-x")
+	mockPc.EXPECT().Println("UNSET_ONLY=")
+	mockPc.EXPECT().Println("ALT=with-app")
+	mockPc.EXPECT().Println("ALT_EMPTY=")
+	mockPc.EXPECT().Println(`BRACES_IN_DEFAULT={"a": 1}`)
+	mockPc.EXPECT().Println("ESCAPED_BRACE=a}b")
+	mockPc.EXPECT().Println("ESCAPED=${APP}")
+	mockPc.EXPECT().Println("LOWER=catalog-api")
+	mockPc.EXPECT().Println("UPPER_REPLACED=CATALOG_API")
+	mockPc.EXPECT().Println("DEFAULT_FN=fallback")
+	mockPc.EXPECT().Println("REQUIRED=Catalog-Api")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/tmp/workspaces/project1/apps/test")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/apps/test/docker-compose.yml")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceVarsRequiredError(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, `
+name: ensi
+variables:
+  REQUIRED: "prefix ${UNDEFINED:?define it in env.yaml}"
+`, "")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err == nil || err.Error() != "variable UNDEFINED: define it in env.yaml (position 8 in 'prefix ${UNDEFINED:?define it in env.yaml}')" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceVarsUnknownNamespace(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, `
+name: ensi
+variables:
+  BAD: "${APP:x}"
+`, "")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err == nil || err.Error() != "unknown namespace APP, expected one of env, secret (position 1 in '${APP:x}')" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithLazyVars = `
+name: ensi
+variables:
+  APP1_PATH: ${APPS_ROOT}/app1
+  APPS_ROOT: ${APPS_ROOT:-${WORKSPACE_PATH}/apps}
+services:
+  test:
+    path: "${APP1_PATH}"
+    extends: tpl
+    variables:
+      APP_PORT: "8080"
+templates:
+  tpl:
+    path: "${WORKSPACE_PATH}/templates/tpl"
+    variables:
+      APP_URL: http://${APP_NAME}:${APP_PORT}
+`
+
+const envConfigWithLazyVars = `
+variables:
+  APPS_ROOT: /home/user/apps
+`
+
+func TestServiceVarsLazy(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLazyVars, envConfigWithLazyVars)
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("APP1_PATH=/home/user/apps/app1")
+	mockPc.EXPECT().Println("APPS_ROOT=/home/user/apps")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/home/user/apps/app1")
+	mockPc.EXPECT().Println("TPL_PATH=/tmp/workspaces/project1/templates/tpl")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/templates/tpl/docker-compose.yml")
+	mockPc.EXPECT().Println("APP_URL=http://test:8080")
+	mockPc.EXPECT().Println("APP_PORT=8080")
+
+	err := PrintVarsAction(&core.GlobalOptions{ComponentName: "test"}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceVarsCycle(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, `
+name: ensi
+variables:
+  A: ${B}
+  B: x${C}
+  C: ${A}
+`, "")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err == nil || err.Error() != "variables have circular reference: A -> B -> C -> A" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithHostEnv = `
+name: ensi
+env_passthrough: [CI, SSH_AUTH_SOCK]
+variables:
+  HOME_DIR: ${env:HOME}
+  IS_CI: ${CI:-false}
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceVarsWithHostEnv(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithHostEnv, "")
+
+	mockPc.EXPECT().LookupEnv("CI").Return("true", true)
+	mockPc.EXPECT().LookupEnv("SSH_AUTH_SOCK").Return("", false)
+	mockPc.EXPECT().LookupEnv("HOME").Return("/home/user", true)
+
+	mockPc.EXPECT().Println("WORKSPACE_PATH=/tmp/workspaces/project1")
+	mockPc.EXPECT().Println("WORKSPACE_NAME=ensi")
+	expectHostVariablesPrinted(mockPc)
+	mockPc.EXPECT().Println("CI=true")
+	mockPc.EXPECT().Println("HOME_DIR=/home/user")
+	mockPc.EXPECT().Println("IS_CI=true")
+	mockPc.EXPECT().Println("APP_NAME=test")
+	mockPc.EXPECT().Println("COMPOSE_PROJECT_NAME=ensi-test")
+	mockPc.EXPECT().Println("SVC_PATH=/tmp/workspaces/project1/apps/test")
+	mockPc.EXPECT().Println("COMPOSE_FILE=/tmp/workspaces/project1/apps/test/docker-compose.yml")
+
+	err := PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakeFileInfo struct {
+	name  string
+	isDir bool
+}
+
+func (fi fakeFileInfo) Name() string       { return fi.name }
+func (fi fakeFileInfo) Size() int64        { return 0 }
+func (fi fakeFileInfo) Mode() os.FileMode  { return 0755 }
+func (fi fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fakeFileInfo) IsDir() bool        { return fi.isDir }
+func (fi fakeFileInfo) Sys() interface{}   { return nil }
+
+func TestHookRun(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	composeFilePath := path.Join(svcPath, "docker-compose.yml")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false).Times(3)
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git_hooks/pre-push")).Return(true)
+	mockPc.EXPECT().ReadDir(path.Join(svcPath, ".git_hooks/pre-push")).Return([]os.FileInfo{
+		fakeFileInfo{name: "01-test.sh"},
+		fakeFileInfo{name: "02-notify.host.sh"},
+		fakeFileInfo{name: "disabled", isDir: true},
+	}, nil)
+	mockPc.EXPECT().ReadStdin().Return([]byte("refs/heads/master 123 refs/heads/master 456\n"), nil)
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/dep2/docker-compose.yml"))
+	expectStartService(mockPc, composeFilePath)
+	mockPc.EXPECT().
+		ExecWithInput([]string{"docker", "compose", "-f", composeFilePath, "exec", "-u", "1000:1000", "-T", "app", "./.git_hooks/pre-push/01-test.sh", "origin", "git@host:repo.git"}, gomock.Any(), []byte("refs/heads/master 123 refs/heads/master 456\n")).
+		Return(0, nil)
+	mockPc.EXPECT().
+		ExecWithInput([]string{path.Join(svcPath, ".git_hooks/pre-push/02-notify.host.sh"), "origin", "git@host:repo.git"}, gomock.Any(), gomock.Any()).
+		Return(0, nil)
+
+	err := RunHookAction(&core.GlobalOptions{UID: -1}, ".git_hooks", "pre-push", []string{"origin", "git@host:repo.git"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHookRunSkipped(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("pre-commit,pre-push", true)
+	mockPc.EXPECT().Printf("Hook %s is skipped by ELC_SKIP_HOOKS\n", "pre-commit")
+
+	err := RunHookAction(&core.GlobalOptions{UID: -1}, ".git_hooks", "pre-commit", []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHookRunStopsOnFailure(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false).Times(2)
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git_hooks/pre-commit")).Return(true)
+	mockPc.EXPECT().ReadDir(path.Join(svcPath, ".git_hooks/pre-commit")).Return([]os.FileInfo{
+		fakeFileInfo{name: "lint.host.sh"},
+		fakeFileInfo{name: "test.host.sh"},
+	}, nil)
+	mockPc.EXPECT().
+		ExecInteractive([]string{path.Join(svcPath, ".git_hooks/pre-commit/lint.host.sh")}, gomock.Any()).
+		Return(1, errors.New("exit status 1"))
+
+	err := RunHookAction(&core.GlobalOptions{UID: -1}, ".git_hooks", "pre-commit", []string{})
+	if err == nil || err.Error() != "hook script ./.git_hooks/pre-commit/lint.host.sh failed: exit status 1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func expectHookFolders(mockPc *core.MockPC, svcPath string) {
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git_hooks/pre-commit")).Return(true)
+	mockPc.EXPECT().ReadDir(path.Join(svcPath, ".git_hooks/pre-commit")).Return([]os.FileInfo{
+		fakeFileInfo{name: "lint.sh"},
+	}, nil)
+}
+
+func TestSetHooks(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	hooksPath := path.Join(svcPath, ".git/hooks")
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git")).Return(true)
+	mockPc.EXPECT().FileExists(hooksPath).Return(true)
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git_hooks")).Return(true)
+	expectHookFolders(mockPc, svcPath)
+
+	// user's own pre-commit hook is kept and chained
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "pre-commit")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(hooksPath, "pre-commit")).Return([]byte("#!/bin/sh\nmake lint\n"), nil)
+	mockPc.EXPECT().Rename(path.Join(hooksPath, "pre-commit"), path.Join(hooksPath, "pre-commit.pre-elc")).Return(nil)
+	mockPc.EXPECT().WriteFile(path.Join(hooksPath, "pre-commit"), gomock.Any(), gomock.Any()).Return(nil)
+	mockPc.EXPECT().Chmod(path.Join(hooksPath, "pre-commit"), gomock.Any()).Return(nil)
+
+	// wrapper of hook without scripts is removed
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "pre-push")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(hooksPath, "pre-push")).Return([]byte("#!/bin/bash\n# generated by elc, do not edit\n"), nil)
+	mockPc.EXPECT().Remove(path.Join(hooksPath, "pre-push")).Return(nil)
+
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+	mockPc.EXPECT().Println(gomock.Any())
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, ".git_hooks", "elc", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wrapper generated by elc before hooks got signature line
+const legacyHookScript = `#!/bin/bash
+set -e
+
+ELC_BINARY="elc"
+HOOKS_FOLDER=".git_hooks"
+HOOK_NAME="%s"
+
+if command -v $ELC_BINARY &> /dev/null; then
+    $ELC_BINARY --mode=hook --no-tty $0
+else
+    for script in ./$HOOKS_FOLDER/$HOOK_NAME/* ; do
+        if [ -f $script ]; then
+            $script
+        fi
+    done
+fi
+`
+
+func TestSetHooksMigratesLegacyWrappers(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	hooksPath := path.Join(svcPath, ".git/hooks")
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git")).Return(true)
+	mockPc.EXPECT().FileExists(hooksPath).Return(true)
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git_hooks")).Return(true)
+	expectHookFolders(mockPc, svcPath)
+
+	// old wrapper of used hook is overwritten, not chained
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "pre-commit")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(hooksPath, "pre-commit")).Return([]byte(fmt.Sprintf(legacyHookScript, "pre-commit")), nil)
+	mockPc.EXPECT().WriteFile(path.Join(hooksPath, "pre-commit"), gomock.Any(), gomock.Any()).Return(nil)
+	mockPc.EXPECT().Chmod(path.Join(hooksPath, "pre-commit"), gomock.Any()).Return(nil)
+
+	// old wrapper of hook without scripts is removed
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "post-merge")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(hooksPath, "post-merge")).Return([]byte(fmt.Sprintf(legacyHookScript, "post-merge")), nil)
+	mockPc.EXPECT().Remove(path.Join(hooksPath, "post-merge")).Return(nil)
+
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+	mockPc.EXPECT().Println(gomock.Any())
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, ".git_hooks", "elc", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetHooksCheckLegacyWrappers(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	// component has no hooks, so old wrapper is reported as not used
+	hookPath := path.Join(fakeWorkspacePath, "apps/test/.git/hooks/post-merge")
+	mockPc.EXPECT().FileExists(hookPath).Return(true)
+	mockPc.EXPECT().ReadFile(hookPath).Return([]byte(fmt.Sprintf(legacyHookScript, "post-merge")), nil)
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, "", "elc", false, true)
+	if err == nil || err.Error() != "hooks are outdated, run 'elc set-hooks' to update them" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetHooksUninstall(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	hooksPath := path.Join(fakeWorkspacePath, "apps/test/.git/hooks")
+	mockPc.EXPECT().FileExists(hooksPath).Return(true)
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "pre-commit")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(hooksPath, "pre-commit")).Return([]byte("#!/bin/bash\n# generated by elc, do not edit\n"), nil)
+	mockPc.EXPECT().Remove(path.Join(hooksPath, "pre-commit")).Return(nil)
+	mockPc.EXPECT().FileExists(path.Join(hooksPath, "pre-commit.pre-elc")).Return(true)
+	mockPc.EXPECT().Rename(path.Join(hooksPath, "pre-commit.pre-elc"), path.Join(hooksPath, "pre-commit")).Return(nil)
+
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+	mockPc.EXPECT().Println(gomock.Any())
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, "", "elc", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetHooksCheckOutdated(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	expectHookFolders(mockPc, svcPath)
+	mockPc.EXPECT().FileExists(path.Join(svcPath, ".git/hooks/pre-commit")).Return(true)
+	mockPc.EXPECT().ReadFile(path.Join(svcPath, ".git/hooks/pre-commit")).Return([]byte("#!/bin/bash\n# generated by elc, do not edit\n"), nil)
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, ".git_hooks", "elc", false, true)
+	if err == nil || err.Error() != "hooks are outdated, run 'elc set-hooks' to update them" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithHooks = `
+name: ensi
+variables:
+  USER_ID: "1000"
+  GROUP_ID: "1000"
+templates:
+  php:
+    path: "${WORKSPACE_PATH}/templates/php"
+    hooks:
+      pre-commit:
+        - elc exec composer lint
+        - composer test -- --changed
+      pre-push: [ "composer audit" ]
+services:
+  test:
+    extends: php
+    path: "${WORKSPACE_PATH}/apps/test"
+    hooks:
+      pre-push: []
+`
+
+func TestHookRunFromConfig(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithHooks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "templates/php/docker-compose.yml")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	expectStartService(mockPc, composeFilePath)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/test")).Return(true)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, "running-container-id", nil)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "exec", "-u", "1000:1000", "-T", "app", "sh", "-c", "composer lint", "pre-commit"}, gomock.Any()).
+		Return(0, nil)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "exec", "-u", "1000:1000", "-T", "app", "sh", "-c", "composer test -- --changed", "pre-commit"}, gomock.Any()).
+		Return(0, nil)
+
+	err := RunHookAction(&core.GlobalOptions{UID: -1}, "", "pre-commit", []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetHooksFromConfig(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithHooks, "")
+
+	hooksPath := path.Join(fakeWorkspacePath, "apps/test/.git/hooks")
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/test/.git")).Return(true)
+	mockPc.EXPECT().FileExists(hooksPath).Return(true)
+	// pre-push of template is disabled by component, so only pre-commit is installed
+	mockPc.EXPECT().WriteFile(path.Join(hooksPath, "pre-commit"), gomock.Any(), gomock.Any()).Return(nil)
+	mockPc.EXPECT().Chmod(path.Join(hooksPath, "pre-commit"), gomock.Any()).Return(nil)
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(false).AnyTimes()
+	mockPc.EXPECT().Println(gomock.Any())
+
+	err := SetGitHooksAction(&core.GlobalOptions{ComponentName: "test"}, "", "elc", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const workspaceConfigWithLifecycleHooks = `
+name: ensi
+variables:
+  USER_ID: "1000"
+  GROUP_ID: "1000"
+templates:
+  php:
+    path: "${WORKSPACE_PATH}/templates/php"
+    compose_file: "${SVC_PATH}/docker-compose.yml"
+    hooks:
+      before_start: [ "./prepare.sh --force" ]
+      after_start: [ "exec: php artisan migrate" ]
+      before_stop: [ "exec: php artisan queue:stop" ]
+services:
+  test:
+    extends: php
+    path: "${WORKSPACE_PATH}/apps/test"
+    repository: "git@host:test.git"
+    after_clone_hook: "${SVC_PATH}/after-clone.sh"
+    hooks:
+      after_pull: [ "composer install", "exit 2", "never executed" ]
+`
+
+func TestServiceStartWithLifecycleHooks(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false).Times(2)
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecInteractive([]string{"sh", "-c", "cd \"$SVC_PATH\" && ./prepare.sh --force"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "up", "-d"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "exec", "-u", "1000:1000", "-T", "app", "sh", "-c", "php artisan migrate"}, gomock.Any()).
+			Return(0, nil),
+	)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/test")).Return(true)
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "", nil),
+		mockPc.EXPECT().
+			ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "running-container-id", nil),
+	)
+	mockPc.EXPECT().IsTerminal().Return(false)
+
+	comp := loadComponent(t, "test")
+	err := comp.Start(&core.GlobalOptions{UID: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartAbortedByHook(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/test")).Return(true)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"sh", "-c", "cd \"$SVC_PATH\" && ./prepare.sh --force"}, gomock.Any()).
+		Return(1, nil)
+
+	comp := loadComponent(t, "test")
+	err := comp.Start(&core.GlobalOptions{UID: -1})
+	if err == nil || err.Error() != "hook before_start failed on command './prepare.sh --force' with exit code 1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceCloneFailed(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	mockPc.EXPECT().FileExists(svcPath).Return(false).Times(2)
+	// hook after_clone must not run
+	mockPc.EXPECT().
+		ExecInteractive([]string{"git", "clone", "git@host:test.git", svcPath}, gomock.Any()).
+		Return(128, nil)
+
+	comp := loadComponent(t, "test")
+	err := comp.Clone(&core.GlobalOptions{}, false)
+	if err == nil || err.Error() != "git clone failed with exit code 128" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceCloneWithLegacyHook(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	mockPc.EXPECT().FileExists(svcPath).Return(false).Times(2)
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecInteractive([]string{"git", "clone", "git@host:test.git", svcPath}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"sh", "-c", "cd \"$SVC_PATH\" && " + path.Join(svcPath, "after-clone.sh")}, gomock.Any()).
+			Return(0, nil),
+	)
+
+	comp := loadComponent(t, "test")
+	err := comp.Clone(&core.GlobalOptions{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServicePullStopsOnFailedHook(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	svcPath := path.Join(fakeWorkspacePath, "apps/test")
+	mockPc.EXPECT().FileExists(svcPath).Return(true)
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecInteractive([]string{"git", "-C", svcPath, "pull"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"sh", "-c", "cd \"$SVC_PATH\" && composer install"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"sh", "-c", "cd \"$SVC_PATH\" && exit 2"}, gomock.Any()).
+			Return(2, nil),
+	)
+
+	comp := loadComponent(t, "test")
+	err := comp.Pull(&core.GlobalOptions{}, false)
+	if err == nil || err.Error() != "hook after_pull failed on command 'exit 2' with exit code 2" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithContainerHooks = `
+name: ensi
+variables:
+  USER_ID: "1000"
+  GROUP_ID: "1000"
+services:
+  app:
+    path: "${WORKSPACE_PATH}/apps/app"
+  lib:
+    path: "${WORKSPACE_PATH}/packages/lib"
+    hosted_in: app
+    hooks:
+      after_pull: [ "exec: cd ${SVC_PATH} && composer install" ]
+`
+
+func TestModulePullWithContainerHook(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithContainerHooks, "")
+
+	libPath := path.Join(fakeWorkspacePath, "packages/lib")
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/app/docker-compose.yml")
+	mockPc.EXPECT().FileExists(libPath).Return(true)
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	mockPc.EXPECT().IsTerminal().Return(false)
+	// command is rendered with variables of module and executed in container of hosting service
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecInteractive([]string{"git", "-C", libPath, "pull"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "running-container-id", nil),
+		mockPc.EXPECT().
+			ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "exec", "-u", "1000:1000", "-T", "app", "sh", "-c", "cd " + libPath + " && composer install"}, gomock.Any()).
+			Return(0, nil),
+	)
+
+	comp := loadComponent(t, "lib")
+	err := comp.Pull(&core.GlobalOptions{UID: -1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModulePullWithContainerHookSkipped(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithContainerHooks, "")
+
+	libPath := path.Join(fakeWorkspacePath, "packages/lib")
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/app/docker-compose.yml")
+	mockPc.EXPECT().FileExists(libPath).Return(true)
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("", false)
+	// hook must not start stopped service
+	gomock.InOrder(
+		mockPc.EXPECT().
+			ExecInteractive([]string{"git", "-C", libPath, "pull"}, gomock.Any()).
+			Return(0, nil),
+		mockPc.EXPECT().
+			ExecToString([]string{"docker", "compose", "-f", composeFilePath, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "", nil),
+		mockPc.EXPECT().
+			Printf("Command '%s' of hook %s is skipped, service %s is not running\n", "exec: cd ${SVC_PATH} && composer install", "after_pull", "app"),
+	)
+
+	comp := loadComponent(t, "lib")
+	err := comp.Pull(&core.GlobalOptions{UID: -1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStopWithSkippedHook(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithLifecycleHooks, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+	mockPc.EXPECT().LookupEnv("ELC_SKIP_HOOKS").Return("before_stop", true)
+	mockPc.EXPECT().Printf("Hook %s is skipped by ELC_SKIP_HOOKS\n", "before_stop")
+	expectStopService(mockPc, composeFilePath)
+
+	comp := loadComponent(t, "test")
+	err := comp.Stop(&core.GlobalOptions{UID: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadComponent(t *testing.T, name string) *core.Component {
+	ws, err := core.GetWorkspaceConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comp, err := ws.ComponentByName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return comp
+}
+
+const workspaceConfigForList = `
+name: ensi
+aliases:
+  be: backend
+templates:
+  php:
+    path: "${WORKSPACE_PATH}/templates/php"
+services:
+  proxy:
+    path: "${WORKSPACE_PATH}/apps/proxy"
+    tags: [infra]
+  backend:
+    extends: php
+    path: "${WORKSPACE_PATH}/apps/backend"
+    alias: api
+    tags: [core]
+    hosted_in: proxy
+  frontend:
+    path: "${WORKSPACE_PATH}/apps/frontend"
+    tags: [core, js]
+`
+
+func expectDockerPs(mockPc *core.MockPC, out string) {
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "ps", "--format", "{{.Label \"com.docker.compose.project\"}}"}, gomock.Any()).
+		Return(0, out, nil)
+}
+
+func TestServiceListJson(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectDockerPs(mockPc, "ensi-proxy\n")
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/backend")).Return(true)
+	mockPc.EXPECT().Println(`[
+  {
+    "name": "backend",
+    "type": "module",
+    "path": "/tmp/workspaces/project1/apps/backend",
+    "tags": [
+      "core"
+    ],
+    "template": "php",
+    "hosted_in": "proxy",
+    "aliases": [
+      "api",
+      "be"
+    ],
+    "cloned": true,
+    "running": true
+  }
+]`)
+
+	err := ListServicesAction(&core.GlobalOptions{Output: "json", Filter: core.ComponentFilter{Type: "module"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceVarsYaml(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	mockPc.EXPECT().Println(`- name: WORKSPACE_PATH
+  value: /tmp/workspaces/project1
+- name: WORKSPACE_NAME
+  value: ensi
+` + hostVariablesYaml("USER_ID", "GROUP_ID", "USER_NAME", "HOME", "HOST_OS", "HOST_ARCH") + `- name: APP_NAME
+  value: test
+- name: COMPOSE_PROJECT_NAME
+  value: ensi-test
+- name: SVC_PATH
+  value: /tmp/workspaces/project1/apps/test
+- name: COMPOSE_FILE
+  value: /tmp/workspaces/project1/apps/test/docker-compose.yml`)
+
+	err := PrintVarsAction(&core.GlobalOptions{Output: "yaml"}, []string{"test"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceListUnknownOutput(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	expectDockerPs(mockPc, "")
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(true)
+
+	err := ListServicesAction(&core.GlobalOptions{Output: "xml"}, false)
+	if err == nil || err.Error() != "unknown output format 'xml', expected one of: text, json, yaml" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceListTable(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectDockerPs(mockPc, "ensi-proxy\nother-project\n")
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/backend")).Return(true)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/frontend")).Return(false)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/proxy")).Return(true)
+	mockPc.EXPECT().Printf("%s", ""+
+		"NAME      TYPE     EXTENDS  HOSTED_IN  TAGS     CLONED  RUNNING\n"+
+		"backend   module   php      proxy      core     yes     yes\n"+
+		"frontend  service  -        -          core,js  no      no\n"+
+		"proxy     service  -        -          infra    yes     yes\n")
+
+	err := ListServicesAction(&core.GlobalOptions{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceListWithoutRuntime(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "ps", "--format", "{{.Label \"com.docker.compose.project\"}}"}, gomock.Any()).
+		Return(-1, "", errors.New("exec: \"docker\": executable file not found in $PATH"))
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/backend")).Return(true)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/frontend")).Return(false)
+	mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/proxy")).Return(true)
+	mockPc.EXPECT().Printf("%s", ""+
+		"NAME      TYPE     EXTENDS  HOSTED_IN  TAGS     CLONED  RUNNING\n"+
+		"backend   module   php      proxy      core     yes     -\n"+
+		"frontend  service  -        -          core,js  no      -\n"+
+		"proxy     service  -        -          infra    yes     -\n")
+
+	err := ListServicesAction(&core.GlobalOptions{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceListFiltered(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectDockerPs(mockPc, "ensi-frontend\n")
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(true).Times(2)
+	mockPc.EXPECT().Println("frontend")
+
+	err := ListServicesAction(&core.GlobalOptions{Filter: core.ComponentFilter{
+		Tags:    []string{"core", "infra"},
+		NotTags: []string{"php"},
+		Type:    "service",
+		Running: true,
+	}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartByTags(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/frontend/docker-compose.yml"))
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/proxy/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{Filter: core.ComponentFilter{Tags: []string{"js", "infra"}}}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartByTagNotFound(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	err := StartServiceAction(&core.GlobalOptions{Filter: core.ComponentFilter{Tags: []string{"db"}, Extends: "php"}}, []string{})
+	if err == nil || err.Error() != "components with tag db, extends php not found" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithDepsTree = `
+name: ensi
+services:
+  db:
+    path: "${WORKSPACE_PATH}/apps/db"
+  auth:
+    path: "${WORKSPACE_PATH}/apps/auth"
+    dependencies:
+      db: [default]
+  gateway:
+    path: "${WORKSPACE_PATH}/apps/gateway"
+    dependencies:
+      cache: [default, hard]
+      auth: [default]
+  cache:
+    path: "${WORKSPACE_PATH}/apps/cache"
+`
+
+func TestServiceStartSelectors(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/frontend/docker-compose.yml"))
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/proxy/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"fr*", "proxy,!tag:php"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartOnlyExcludes(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/proxy/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"!tag:core"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStopDepsOf(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDepsTree, "")
+
+	gomock.InOrder(
+		mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/db")).Return(false),
+		mockPc.EXPECT().Println("component is not cloned"),
+		mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/auth")).Return(false),
+		mockPc.EXPECT().Println("component is not cloned"),
+		mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps/cache")).Return(false),
+		mockPc.EXPECT().Println("component is not cloned"),
+	)
+
+	err := StopServiceAction(false, []string{"deps-of:gateway"}, false, &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceSelectorWithFilter(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/frontend/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{
+		ComponentName: "all",
+		Filter:        core.ComponentFilter{Tags: []string{"js"}},
+	}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceSelectorNotFound(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"catalog-*"})
+	if err == nil || err.Error() != "components matching 'catalog-*' not found" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceSelectorMatchesNothing(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForList, "")
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"all,!tag:core,!proxy"})
+	if err == nil || err.Error() != "selector 'all,!tag:core,!proxy' matches no components" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigNested = `
+name: ensi
+templates:
+  base:
+    path: "${WORKSPACE_PATH}/apps"
+services:
+  shop:
+    path: "${WORKSPACE_PATH}/apps/shop"
+  shop-cart:
+    path: "${WORKSPACE_PATH}/apps/shop/modules/cart"
+  shop-admin:
+    path: "${WORKSPACE_PATH}/apps/shop-admin"
+`
+
+func TestServiceStartNestedComponentByPath(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfigInDir(mockPc, fakeWorkspacePath, path.Join(fakeWorkspacePath, "apps/shop/modules/cart/src"), workspaceConfigNested, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/shop/modules/cart/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartParentComponentByPath(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfigInDir(mockPc, fakeWorkspacePath, path.Join(fakeWorkspacePath, "apps/shop/src"), workspaceConfigNested, "")
+
+	expectStartService(mockPc, path.Join(fakeWorkspacePath, "apps/shop/docker-compose.yml"))
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartOutsideComponents(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfigInDir(mockPc, fakeWorkspacePath, path.Join(fakeWorkspacePath, "apps"), workspaceConfigNested, "")
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err == nil || err.Error() != "you are not in component folder" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceStartDependenciesOrder(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDepsTree, "")
+
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(true).AnyTimes()
+	mockPc.EXPECT().ExecToString(gomock.Any(), gomock.Any()).Return(0, "", nil).AnyTimes()
+
+	var calls []*gomock.Call
+	for _, name := range []string{"db", "auth", "cache", "gateway"} {
+		composeFile := path.Join(fakeWorkspacePath, "apps", name, "docker-compose.yml")
+		calls = append(calls, mockPc.EXPECT().
+			ExecInteractive([]string{"docker", "compose", "-f", composeFile, "up", "-d"}, gomock.Any()).
+			Return(0, nil))
+	}
+	gomock.InOrder(calls...)
+
+	err := StartServiceAction(&core.GlobalOptions{Mode: "default"}, []string{"gateway"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const homeConfigWithSettings = `
+current_workspace: project1
+defaults:
+  mode: hook
+  variables:
+    BASE_DOMAIN: default.local
+    USER_ID: 1000
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1
+  compose_runtime: podman
+  variables:
+    BASE_DOMAIN: ${USER_ID}.my.local
+    API_URL: https://${BASE_DOMAIN}
+`
+
+const workspaceConfigWithSettings = `
+name: ensi
+variables:
+  BASE_DOMAIN: shared.local
+  API_URL: http://${BASE_DOMAIN}
+  LOG_LEVEL: info
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func TestServiceVarsHomeOverrides(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, homeConfigWithSettings)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithSettings, `
+variables:
+  API_URL: http://localhost
+`)
+
+	mockPc.EXPECT().Println(`- name: WORKSPACE_PATH
+  value: /tmp/workspaces/project1
+- name: WORKSPACE_NAME
+  value: ensi
+` + hostVariablesYaml("GROUP_ID", "USER_NAME", "HOME", "HOST_OS", "HOST_ARCH") + `- name: LOG_LEVEL
+  value: info
+- name: USER_ID
+  value: "1000"
+- name: BASE_DOMAIN
+  value: 1000.my.local
+- name: API_URL
+  value: http://localhost
+- name: APP_NAME
+  value: test
+- name: COMPOSE_PROJECT_NAME
+  value: ensi-test
+- name: SVC_PATH
+  value: /tmp/workspaces/project1/apps/test
+- name: COMPOSE_FILE
+  value: /tmp/workspaces/project1/apps/test/docker-compose.yml`)
+
+	err := PrintVarsAction(&core.GlobalOptions{Output: "yaml"}, []string{"test"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartHomeSettings(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, homeConfigWithSettings)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	for _, name := range []string{"dep2", "test"} {
+		composeFile := path.Join(fakeWorkspacePath, "apps", name, "docker-compose.yml")
+		mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps", name)).Return(true)
+		mockPc.EXPECT().
+			ExecToString([]string{"podman", "compose", "-f", composeFile, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "", nil)
+		mockPc.EXPECT().
+			ExecInteractive([]string{"podman", "compose", "-f", composeFile, "up", "-d"}, gomock.Any()).
+			Return(0, nil)
+	}
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartModeFlagOverridesHome(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, homeConfigWithSettings)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithDeps, "")
+
+	for _, name := range []string{"dep1", "dep2", "test"} {
+		composeFile := path.Join(fakeWorkspacePath, "apps", name, "docker-compose.yml")
+		mockPc.EXPECT().FileExists(path.Join(fakeWorkspacePath, "apps", name)).Return(true)
+		mockPc.EXPECT().
+			ExecToString([]string{"podman", "compose", "-f", composeFile, "ps", "--status=running", "-q"}, gomock.Any()).
+			Return(0, "", nil)
+		mockPc.EXPECT().
+			ExecInteractive([]string{"podman", "compose", "-f", composeFile, "up", "-d"}, gomock.Any()).
+			Return(0, nil)
+	}
+
+	err := StartServiceAction(&core.GlobalOptions{Mode: "default"}, []string{"test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceUnknownComposeRuntime(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, `
+current_workspace: project1
+defaults:
+  compose_runtime: nerdctl
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1
+`)
+	mockPc.EXPECT().Getwd().Return(path.Join(fakeWorkspacePath, "apps/test"), nil)
+	expectNoLocalWorkspace(mockPc, path.Join(fakeWorkspacePath, "apps/test"))
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{"test"})
+	if err == nil || err.Error() != "unknown compose runtime 'nerdctl', expected one of: docker, docker-compose, podman" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func expectReadAllWorkspaces(mockPC *core.MockPC, config1 string, config2 string) {
+	mockPC.EXPECT().Getwd().Return("/tmp/home", nil)
+	expectReadHomeConfig(mockPC)
+	expectWorkspaceFiles(mockPC, "/tmp/workspaces/project1", config1, "")
+	expectWorkspaceFiles(mockPC, "/tmp/workspaces/project2", config2, "")
+}
+
+func TestServiceStopAllWorkspaces(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadAllWorkspaces(mockPc, workspaceConfig, workspaceConfig)
+
+	expectStopService(mockPc, "/tmp/workspaces/project1/apps/test/docker-compose.yml")
+	expectStopService(mockPc, "/tmp/workspaces/project2/apps/test/docker-compose.yml")
+
+	err := StopServiceAction(true, []string{}, false, &core.GlobalOptions{AllWorkspaces: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartAllWorkspacesWithoutSelection(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadAllWorkspaces(mockPc, workspaceConfig, workspaceConfig)
+
+	err := StartServiceAction(&core.GlobalOptions{AllWorkspaces: true}, []string{})
+	if err == nil || err.Error() != "command failed in 2 of 2 workspaces" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceStartAllWorkspacesSkipsPinnedVersion(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/home", nil)
+	expectReadHomeConfig(mockPc)
+	expectWorkspaceFiles(mockPc, "/tmp/workspaces/project1", workspaceConfig, "")
+	expectWorkspaceConfigFiles(mockPc, "/tmp/workspaces/project2", "name: ensi\nelc_version: \"< 0.0.1\"\nservices: {}\n", "")
+	prevVersion := core.Version
+	core.Version = "v1.0.0"
+	defer func() { core.Version = prevVersion }()
+
+	expectStartService(mockPc, "/tmp/workspaces/project1/apps/test/docker-compose.yml")
+
+	err := StartServiceAction(&core.GlobalOptions{AllWorkspaces: true}, []string{"test"})
+	if err == nil || err.Error() != "command failed in 1 of 2 workspaces" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceStartAllWorkspacesWithWorkspace(t *testing.T) {
+	setupMockPc(t)
+
+	err := StartServiceAction(&core.GlobalOptions{AllWorkspaces: true, WorkspaceName: "project1"}, []string{"test"})
+	if err == nil || err.Error() != "--workspace can not be used with --all-workspaces" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func expectPruneListing(mockPc *core.MockPC) {
+	filter := "label=com.docker.compose.project=ensi-test"
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "ps", "-a", "--filter", filter, "--format", "{{.Names}}"}, gomock.Any()).
+		Return(0, "ensi-test-app-1\nensi-test-old-1\n", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "ls", "--filter", filter, "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-test_data\n", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "image", "ls", "--filter", filter, "--format", "{{.ID}} {{.Repository}}:{{.Tag}}"}, gomock.Any()).
+		Return(0, "1a2b3c ensi-test-app:latest\n4d5e6f <none>:<none>\n", nil)
+
+	mockPc.EXPECT().Printf("%-9s %s\n", "container", "ensi-test-app-1")
+	mockPc.EXPECT().Printf("%-9s %s\n", "container", "ensi-test-old-1")
+	mockPc.EXPECT().Printf("%-9s %s\n", "volume", "ensi-test_data")
+	mockPc.EXPECT().Printf("%-9s %s\n", "image", "ensi-test-app:latest")
+	mockPc.EXPECT().Printf("%-9s %s\n", "image", "4d5e6f")
+}
+
+func TestPrune(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	expectPruneListing(mockPc)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "rm", "-f", "ensi-test-app-1", "ensi-test-old-1"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "rm", "ensi-test_data"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "image", "rm", "4d5e6f", "ensi-test-app:latest"}, gomock.Any()).
+		Return(0, "", nil)
+
+	err := PruneAction(false, true, []string{}, core.PruneOptions{Volumes: true, Images: true}, &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneAllWithWorkspaceNetworks(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	filter := "label=com.docker.compose.project=ensi-test"
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "ps", "-a", "--filter", filter, "--format", "{{.Names}}"}, gomock.Any()).
+		Return(0, "ensi-test-app-1\n", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--filter", filter, "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-test_default\n", nil)
+	// network ensi-shared exists, but it is not created by elc for this workspace, so listing by label skips it
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--filter", "label=elc.workspace=ensi", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi\n", nil)
+
+	mockPc.EXPECT().Printf("%-9s %s\n", "container", "ensi-test-app-1")
+	mockPc.EXPECT().Printf("%-9s %s\n", "network", "ensi-test_default")
+	mockPc.EXPECT().Printf("%-9s %s\n", "network", "ensi")
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "rm", "-f", "ensi-test-app-1"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "rm", "ensi", "ensi-test_default"}, gomock.Any()).
+		Return(0, "", nil)
+
+	err := PruneAction(true, true, []string{}, core.PruneOptions{Networks: true}, &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneDryRun(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	expectPruneListing(mockPc)
+
+	err := PruneAction(false, false, []string{}, core.PruneOptions{Volumes: true, Images: true}, &core.GlobalOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneCancelled(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	expectPruneListing(mockPc)
+
+	mockPc.EXPECT().IsStdinTerminal().Return(true)
+	mockPc.EXPECT().Printf("Remove %d resources? [y/N] ", 5)
+	mockPc.EXPECT().ReadLine().Return("n", nil)
+	mockPc.EXPECT().Println("cancelled")
+
+	err := PruneAction(false, false, []string{}, core.PruneOptions{Volumes: true, Images: true}, &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneWithoutTerminal(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+	expectPruneListing(mockPc)
+
+	mockPc.EXPECT().IsStdinTerminal().Return(false)
+
+	err := PruneAction(false, false, []string{}, core.PruneOptions{Volumes: true, Images: true}, &core.GlobalOptions{})
+	if err == nil || err.Error() != "confirmation is required, run with --yes to prune without prompt" {
+		t.Errorf("unexpected error: %v", err)
+	}
 }
@@ -1,39 +1,54 @@
 package actions
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ensi-platform/elc/core"
 )
 
-func UpdateBinaryAction(version string) error {
-	env := make([]string, 0)
-	if version != "" {
-		env = append(env, fmt.Sprintf("VERSION=%s", version))
-	}
-
+func UpdateBinaryAction(version string, targetPath string, rollback bool) error {
 	hc, err := core.CheckAndLoadHC()
 	if err != nil {
 		return err
 	}
 
-	_, err = core.Pc.ExecInteractive([]string{"bash", "-c", hc.UpdateCommand}, env)
-	if err != nil {
-		return err
+	if rollback {
+		return core.RollbackBinary(hc, targetPath)
 	}
 
-	return nil
+	return core.UpdateBinary(hc, version, targetPath)
 }
 
 func FixUpdateBinaryCommandAction() error {
-	hc, err := core.CheckAndLoadHC()
+	return core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		hc.UpdateCommand = ""
+		hc.UpdateMirror = ""
+		return nil
+	})
+}
+
+func DoctorAction(options *core.GlobalOptions) error {
+	checks := core.Diagnose(options)
+	err := core.PrintOutput(options.Output, checks, func() {
+		for _, check := range checks {
+			_, _ = core.Pc.Printf("%-9s %s: %s\n", "["+check.Status+"]", check.Name, check.Message)
+			if check.Status != core.CheckOk && check.Fix != "" {
+				_, _ = core.Pc.Printf("%-9s fix: %s\n", "", check.Fix)
+			}
+		}
+	})
 	if err != nil {
 		return err
 	}
 
-	hc.UpdateCommand = core.DefaultUpdateCommand
-	err = core.SaveHomeConfig(hc)
-	if err != nil {
-		return err
+	failed := 0
+	for _, check := range checks {
+		if check.Status == core.CheckError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.New(fmt.Sprintf("found %d problems", failed))
 	}
 
 	return nil
@@ -0,0 +1,358 @@
+package actions
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ensi-platform/elc/core"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+)
+
+const fakeBinaryPath = "/tmp/bin/elc"
+
+var fakeAssetName = fmt.Sprintf("elc_%s_%s", runtime.GOOS, runtime.GOARCH)
+
+// startReleaseServer serves release index of github api, only release v2.0.0 has assets
+func startReleaseServer(t *testing.T, binary []byte, checksum string, signature string) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	release := func() string {
+		return fmt.Sprintf(`{"tag_name": "v2.0.0", "assets": [
+			{"name": "%[2]s", "browser_download_url": "%[1]s/download/%[2]s"},
+			{"name": "%[2]s.sig", "browser_download_url": "%[1]s/download/%[2]s.sig"},
+			{"name": "checksums.txt", "browser_download_url": "%[1]s/download/checksums.txt"}
+		]}`, server.URL, fakeAssetName)
+	}
+	mux.HandleFunc("/releases/tags/v2.0.0", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(release()))
+	})
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `[{"tag_name": "v3.0.0", "assets": []}, %s, {"tag_name": "v1.9.0", "assets": []}]`, release())
+	})
+	mux.HandleFunc("/download/"+fakeAssetName, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(binary)
+	})
+	mux.HandleFunc("/download/"+fakeAssetName+".sig", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(signature))
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s  elc_other_arch\n%s  %s\n", checksum, checksum, fakeAssetName)
+	})
+
+	return server
+}
+
+func expectReadUpdateHomeConfig(mockPc *core.MockPC, mirror string, publicKey string) {
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(true)
+	mockPc.EXPECT().ReadFile(fakeHomeConfigPath).Return([]byte(fmt.Sprintf(`
+current_workspace: project1
+update_mirror: %s
+update_public_key: "%s"
+update_binary_path: %s
+`, mirror, publicKey, fakeBinaryPath)), nil)
+}
+
+func sha256String(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUpdateBinary(t *testing.T) {
+	mockPc := setupMockPc(t)
+	binary := []byte("new binary")
+	server := startReleaseServer(t, binary, sha256String(binary), "")
+	expectReadUpdateHomeConfig(mockPc, server.URL, "")
+
+	mockPc.EXPECT().Printf("Downloading %s\n", server.URL+"/download/"+fakeAssetName)
+	gomock.InOrder(
+		mockPc.EXPECT().WriteFile(fakeBinaryPath+".new", binary, gomock.Any()).Return(nil),
+		mockPc.EXPECT().Chmod(fakeBinaryPath+".new", gomock.Any()).Return(nil),
+		mockPc.EXPECT().ExecToString([]string{fakeBinaryPath + ".new", "--version"}, gomock.Any()).Return(0, "elc version v2.0.0", nil),
+		mockPc.EXPECT().FileExists(fakeBinaryPath).Return(true),
+	)
+	if runtime.GOOS == "windows" {
+		// running binary can not be overwritten, so it is moved to .prev
+		mockPc.EXPECT().Remove(fakeBinaryPath + ".old").Return(nil)
+		gomock.InOrder(
+			mockPc.EXPECT().Rename(fakeBinaryPath, fakeBinaryPath+".prev").Return(nil),
+			mockPc.EXPECT().Rename(fakeBinaryPath+".new", fakeBinaryPath).Return(nil),
+		)
+	} else {
+		gomock.InOrder(
+			mockPc.EXPECT().ReadFile(fakeBinaryPath).Return([]byte("old binary"), nil),
+			mockPc.EXPECT().WriteFile(fakeBinaryPath+".prev.tmp", []byte("old binary"), gomock.Any()).Return(nil),
+			mockPc.EXPECT().Rename(fakeBinaryPath+".prev.tmp", fakeBinaryPath+".prev").Return(nil),
+			mockPc.EXPECT().Rename(fakeBinaryPath+".new", fakeBinaryPath).Return(nil),
+		)
+	}
+	mockPc.EXPECT().Printf("elc %s is installed to %s\n", "v2.0.0", fakeBinaryPath)
+
+	err := UpdateBinaryAction("v2.0.0", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateBinaryBadChecksum(t *testing.T) {
+	mockPc := setupMockPc(t)
+	server := startReleaseServer(t, []byte("tampered binary"), sha256String([]byte("new binary")), "")
+	expectReadUpdateHomeConfig(mockPc, server.URL, "")
+
+	mockPc.EXPECT().Printf("Downloading %s\n", gomock.Any())
+
+	// version without prefix resolves to release tag v2.0.0
+	err := UpdateBinaryAction("2.0.0", "", false)
+	if err == nil || err.Error() != fmt.Sprintf("checksum of %s does not match", fakeAssetName) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateBinaryBadSignature(t *testing.T) {
+	mockPc := setupMockPc(t)
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	_, otherPrivateKey, _ := ed25519.GenerateKey(nil)
+
+	binary := []byte("new binary")
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(otherPrivateKey, binary))
+	server := startReleaseServer(t, binary, sha256String(binary), signature)
+	expectReadUpdateHomeConfig(mockPc, server.URL, base64.StdEncoding.EncodeToString(publicKey))
+
+	mockPc.EXPECT().Printf("Downloading %s\n", gomock.Any())
+
+	err := UpdateBinaryAction("v2.0.0", "", false)
+	if err == nil || err.Error() != "signature of binary is not valid" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateBinaryRollback(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadUpdateHomeConfig(mockPc, "", "")
+
+	mockPc.EXPECT().FileExists("/tmp/other/elc.prev").Return(true)
+	if runtime.GOOS == "windows" {
+		mockPc.EXPECT().FileExists("/tmp/other/elc").Return(true)
+		mockPc.EXPECT().Remove("/tmp/other/elc.old").Return(nil)
+		mockPc.EXPECT().Rename("/tmp/other/elc", "/tmp/other/elc.old").Return(nil)
+	}
+	mockPc.EXPECT().Rename("/tmp/other/elc.prev", "/tmp/other/elc").Return(nil)
+	mockPc.EXPECT().Printf("Previous binary is restored to %s\n", "/tmp/other/elc")
+
+	err := UpdateBinaryAction("", "/tmp/other/elc", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const workspaceConfigForDoctor = `
+name: ensi
+variables:
+  NETWORK: ensi
+  USER_ID: "1000"
+  GROUP_ID: "1000"
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func expectDoctorWorkspace(mockPc *core.MockPC) {
+	expectReadHomeConfig(mockPc)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/workspace.yaml").Return(true)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project2/workspace.yaml").Return(true)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigForDoctor, "")
+}
+
+// runDoctor runs doctor with json output and returns statuses of checks by their names
+func runDoctor(t *testing.T, mockPc *core.MockPC) (map[string]string, error) {
+	checks, err := runDoctorChecks(t, mockPc)
+
+	statuses := make(map[string]string)
+	for _, check := range checks {
+		statuses[check.Name] = check.Status
+	}
+
+	return statuses, err
+}
+
+func runDoctorChecks(t *testing.T, mockPc *core.MockPC) ([]core.DoctorCheck, error) {
+	prevVersion := core.Version
+	core.Version = "v1.0.0"
+	t.Cleanup(func() { core.Version = prevVersion })
+
+	var out string
+	mockPc.EXPECT().Println(gomock.Any()).Do(func(a ...interface{}) {
+		out = a[0].(string)
+	})
+
+	err := DoctorAction(&core.GlobalOptions{Output: core.OutputJson})
+
+	var checks []core.DoctorCheck
+	if jsonErr := json.Unmarshal([]byte(out), &checks); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	return checks, err
+}
+
+func findDoctorCheck(checks []core.DoctorCheck, name string) core.DoctorCheck {
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+
+	return core.DoctorCheck{}
+}
+
+func TestDoctorOk(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectDoctorWorkspace(mockPc)
+	mockPc.EXPECT().ExecToString([]string{"docker", "version", "--format", "{{.Server.Version}}"}, gomock.Any()).Return(0, "24.0.5\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"docker", "compose", "version", "--short"}, gomock.Any()).Return(0, "2.20.2\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"git", "--version"}, gomock.Any()).Return(0, "git version 2.40.0\n", nil)
+	mockPc.EXPECT().Getuid().Return(1000)
+	mockPc.EXPECT().ExecToString([]string{"docker", "network", "inspect", "--format", "{{.Name}}", "ensi"}, gomock.Any()).Return(0, "ensi\n", nil)
+
+	statuses, err := runDoctor(t, mockPc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"home config":       core.CheckOk,
+		"elc version":       core.CheckOk,
+		"workspace":         core.CheckOk,
+		"container runtime": core.CheckOk,
+		"compose":           core.CheckOk,
+		"git":               core.CheckOk,
+		"user variables":    core.CheckOk,
+		"network ensi":      core.CheckOk,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(expected) {
+		t.Errorf("unexpected checks: %v", statuses)
+	}
+}
+
+func TestDoctorProblems(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectDoctorWorkspace(mockPc)
+	mockPc.EXPECT().ExecToString([]string{"docker", "version", "--format", "{{.Server.Version}}"}, gomock.Any()).Return(1, "", fmt.Errorf("exit status 1"))
+	mockPc.EXPECT().LookupEnv("DOCKER_HOST").Return("", false)
+	mockPc.EXPECT().FileExists("/var/run/docker.sock").Return(true)
+	mockPc.EXPECT().DialUnix("/var/run/docker.sock").Return(fmt.Errorf("dial unix /var/run/docker.sock: %w", os.ErrPermission))
+	mockPc.EXPECT().ExecToString([]string{"docker", "compose", "version", "--short"}, gomock.Any()).Return(0, "1.29.2\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"git", "--version"}, gomock.Any()).Return(-1, "", fmt.Errorf("executable file not found"))
+	mockPc.EXPECT().Getuid().Return(501)
+
+	statuses, err := runDoctor(t, mockPc)
+	if err == nil || err.Error() != "found 2 problems" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"home config":       core.CheckOk,
+		"elc version":       core.CheckOk,
+		"workspace":         core.CheckOk,
+		"container runtime": core.CheckError,
+		"compose":           core.CheckWarning,
+		"git":               core.CheckError,
+		"user variables":    core.CheckWarning,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(expected) {
+		t.Errorf("unexpected checks: %v", statuses)
+	}
+}
+
+func TestDoctorDaemonStopped(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectDoctorWorkspace(mockPc)
+	mockPc.EXPECT().ExecToString([]string{"docker", "version", "--format", "{{.Server.Version}}"}, gomock.Any()).Return(1, "", fmt.Errorf("exit status 1"))
+	// socket file is left by stopped daemon, but nobody listens it
+	mockPc.EXPECT().LookupEnv("DOCKER_HOST").Return("", false)
+	mockPc.EXPECT().FileExists("/var/run/docker.sock").Return(true)
+	mockPc.EXPECT().DialUnix("/var/run/docker.sock").Return(fmt.Errorf("dial unix /var/run/docker.sock: connect: connection refused"))
+	mockPc.EXPECT().ExecToString([]string{"docker", "compose", "version", "--short"}, gomock.Any()).Return(0, "2.20.2\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"git", "--version"}, gomock.Any()).Return(0, "git version 2.40.0\n", nil)
+	mockPc.EXPECT().Getuid().Return(1000)
+
+	checks, err := runDoctorChecks(t, mockPc)
+	if err == nil || err.Error() != "found 1 problems" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	check := findDoctorCheck(checks, "container runtime")
+	if check.Status != core.CheckError || check.Message != "docker daemon is not running, /var/run/docker.sock does not accept connections" {
+		t.Errorf("unexpected check: %v", check)
+	}
+}
+
+func TestDoctorBrokenHomeConfig(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, "workspaces: [project1,\n")
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath + ".bak").Return(false)
+	mockPc.EXPECT().Getwd().Return(path.Join(fakeWorkspacePath, "apps/test"), nil)
+	mockPc.EXPECT().ExecToString([]string{"docker", "version", "--format", "{{.Server.Version}}"}, gomock.Any()).Return(0, "24.0.5\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"docker", "compose", "version", "--short"}, gomock.Any()).Return(0, "2.20.2\n", nil)
+	mockPc.EXPECT().ExecToString([]string{"git", "--version"}, gomock.Any()).Return(0, "git version 2.40.0\n", nil)
+
+	statuses, err := runDoctor(t, mockPc)
+	if err == nil || err.Error() != "found 2 problems" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"home config":       core.CheckError,
+		"workspace":         core.CheckError,
+		"container runtime": core.CheckOk,
+		"compose":           core.CheckOk,
+		"git":               core.CheckOk,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(expected) {
+		t.Errorf("unexpected checks: %v", statuses)
+	}
+}
+
+func TestDefaultFlagsOfRootCommand(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, `
+current_workspace: project1
+defaults:
+  flags:
+    exec:
+      no-tty: true
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1
+`)
+
+	// 'elc <cmd>' is a short form of 'elc exec <cmd>'
+	commandName := core.DefaultFlagsCommand("elc")
+	if commandName != "exec" {
+		t.Fatalf("unexpected command: %s", commandName)
+	}
+
+	flags, err := core.DefaultFlags(commandName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 || flags["no-tty"] != "true" {
+		t.Errorf("unexpected flags: %v", flags)
+	}
+
+	if commandName = core.DefaultFlagsCommand("elc workspace list"); commandName != "workspace list" {
+		t.Errorf("unexpected command: %s", commandName)
+	}
+}
@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ensi-platform/elc/core"
+	"strings"
+	"text/tabwriter"
+)
+
+func SnapshotSaveAction(compName string, snapshotName string, options *core.GlobalOptions) error {
+	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
+	if err != nil {
+		return err
+	}
+
+	comp, err := ws.ComponentByName(compName)
+	if err != nil {
+		return err
+	}
+
+	snapshotName, err = comp.SaveSnapshot(snapshotName, options)
+	if err != nil {
+		return err
+	}
+
+	_, _ = core.Pc.Printf("snapshot '%s' of service '%s' is saved\n", snapshotName, comp.Name)
+
+	return nil
+}
+
+func SnapshotRestoreAction(compName string, snapshotName string, options *core.GlobalOptions) error {
+	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
+	if err != nil {
+		return err
+	}
+
+	comp, err := ws.ComponentByName(compName)
+	if err != nil {
+		return err
+	}
+
+	err = comp.RestoreSnapshot(snapshotName, options)
+	if err != nil {
+		return err
+	}
+
+	_, _ = core.Pc.Printf("snapshot '%s' of service '%s' is restored\n", snapshotName, comp.Name)
+
+	return nil
+}
+
+// SnapshotListAction prints snapshots of passed services or of all services of workspace.
+func SnapshotListAction(compNames []string, options *core.GlobalOptions) error {
+	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
+	if err != nil {
+		return err
+	}
+
+	if len(compNames) == 0 {
+		compNames = ws.GetComponentNames()
+	}
+
+	snapshots := make([]core.Snapshot, 0)
+	for _, compName := range compNames {
+		comp, err := ws.ComponentByName(compName)
+		if err != nil {
+			return err
+		}
+		if comp.Config.Type() != core.ComponentTypeService {
+			continue
+		}
+
+		compSnapshots, err := comp.Snapshots()
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, compSnapshots...)
+	}
+
+	return core.PrintOutput(options.Output, snapshots, func() {
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "SERVICE\tNAME\tCREATED\tVOLUMES")
+		for _, snapshot := range snapshots {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", snapshot.Component, snapshot.Name, snapshot.Created, strings.Join(snapshot.Volumes, ","))
+		}
+		_ = w.Flush()
+
+		_, _ = core.Pc.Printf("%s", buf.String())
+	})
+}
+
+func SnapshotRemoveAction(compName string, snapshotName string, options *core.GlobalOptions) error {
+	ws, err := core.GetWorkspaceConfig(options.WorkspaceName)
+	if err != nil {
+		return err
+	}
+
+	comp, err := ws.ComponentByName(compName)
+	if err != nil {
+		return err
+	}
+
+	return comp.RemoveSnapshot(snapshotName, options)
+}
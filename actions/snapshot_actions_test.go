@@ -0,0 +1,193 @@
+package actions
+
+import (
+	"errors"
+	"github.com/ensi-platform/elc/core"
+	"github.com/golang/mock/gomock"
+	"os"
+	"path"
+	"testing"
+)
+
+var fakeSnapshotsPath = path.Join(fakeWorkspacePath, ".elc/snapshots/test")
+
+func expectServiceRunning(mockPc *core.MockPC, running bool) {
+	out := ""
+	if running {
+		out = "asdasd"
+	}
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "compose", "-f", path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"), "ps", "--status=running", "-q"}, gomock.Any()).
+		Return(0, out, nil)
+}
+
+func TestSnapshotSave(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	snapshotPath := path.Join(fakeSnapshotsPath, "seed")
+
+	mockPc.EXPECT().FileExists(snapshotPath).Return(false)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "ls", "--filter", "label=com.docker.compose.project=ensi-test", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-test_db\n", nil)
+	expectServiceRunning(mockPc, false)
+
+	// existence of .elc is already expected by lookup of local workspace
+	mockPc.EXPECT().CreateDir(path.Join(fakeWorkspacePath, ".elc")).Return(nil)
+	for _, dirPath := range []string{path.Join(fakeWorkspacePath, ".elc/snapshots"), fakeSnapshotsPath, snapshotPath} {
+		mockPc.EXPECT().FileExists(dirPath).Return(false)
+		mockPc.EXPECT().CreateDir(dirPath).Return(nil)
+	}
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "run", "--rm", "-v", "ensi-test_db:/volume:ro", "-v", snapshotPath + ":/snapshot",
+			"alpine:3", "tar", "-czf", "/snapshot/ensi-test_db.tar.gz", "-C", "/volume", "."}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().Printf("snapshot '%s' of service '%s' is saved\n", "seed", "test")
+
+	err := SnapshotSaveAction("test", "seed", &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotSaveExisting(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	mockPc.EXPECT().FileExists(path.Join(fakeSnapshotsPath, "seed")).Return(true)
+
+	err := SnapshotSaveAction("test", "seed", &core.GlobalOptions{})
+	if err == nil || err.Error() != "snapshot 'seed' of service 'test' already exists, remove it with 'elc snapshot rm test seed'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSnapshotRestoreRunningService(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+	snapshotPath := path.Join(fakeSnapshotsPath, "seed")
+
+	mockPc.EXPECT().IsDir(snapshotPath).Return(true)
+	mockPc.EXPECT().ReadDir(snapshotPath).Return([]os.FileInfo{
+		fakeFileInfo{name: "ensi-test_db.tar.gz"},
+		fakeFileInfo{name: "ensi-test_files.tar.gz"},
+	}, nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "ls", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-test_db\nother_db\n", nil)
+
+	expectServiceRunning(mockPc, true)
+	expectStopService(mockPc, composeFilePath)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "run", "--rm", "-v", "ensi-test_db:/volume", "-v", snapshotPath + ":/snapshot:ro",
+			"alpine:3", "sh", "-c", "find /volume -mindepth 1 -delete && tar -xzf /snapshot/ensi-test_db.tar.gz -C /volume"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "create", "--label", "com.docker.compose.project=ensi-test",
+			"--label", "com.docker.compose.volume=files", "ensi-test_files"}, gomock.Any()).
+		Return(0, "", nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "run", "--rm", "-v", "ensi-test_files:/volume", "-v", snapshotPath + ":/snapshot:ro",
+			"alpine:3", "sh", "-c", "find /volume -mindepth 1 -delete && tar -xzf /snapshot/ensi-test_files.tar.gz -C /volume"}, gomock.Any()).
+		Return(0, "", nil)
+
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(true)
+	expectServiceRunning(mockPc, false)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "up", "-d"}, gomock.Any()).
+		Return(0, nil)
+	mockPc.EXPECT().Printf("snapshot '%s' of service '%s' is restored\n", "seed", "test")
+
+	err := SnapshotRestoreAction("test", "seed", &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotList(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	mockPc.EXPECT().IsDir(fakeSnapshotsPath).Return(true)
+	mockPc.EXPECT().ReadDir(fakeSnapshotsPath).Return([]os.FileInfo{
+		fakeFileInfo{name: "seed", isDir: true},
+		fakeFileInfo{name: "notes.txt"},
+	}, nil)
+	mockPc.EXPECT().ReadDir(path.Join(fakeSnapshotsPath, "seed")).Return([]os.FileInfo{
+		fakeFileInfo{name: "ensi-test_files.tar.gz"},
+		fakeFileInfo{name: "ensi-test_db.tar.gz"},
+	}, nil)
+	mockPc.EXPECT().Printf("%s", ""+
+		"SERVICE  NAME  CREATED              VOLUMES\n"+
+		"test     seed  0001-01-01 00:00:00  ensi-test_db,ensi-test_files\n")
+
+	err := SnapshotListAction([]string{}, &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotRemove(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	snapshotPath := path.Join(fakeSnapshotsPath, "seed")
+	mockPc.EXPECT().IsDir(snapshotPath).Return(true)
+	mockPc.EXPECT().ReadDir(snapshotPath).Return([]os.FileInfo{
+		fakeFileInfo{name: "ensi-test_db.tar.gz"},
+	}, nil)
+	mockPc.EXPECT().Remove(path.Join(snapshotPath, "ensi-test_db.tar.gz")).Return(nil)
+	mockPc.EXPECT().Remove(snapshotPath).Return(nil)
+
+	err := SnapshotRemoveAction("test", "seed", &core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotRestoreFailureStartsService(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfig, "")
+
+	composeFilePath := path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml")
+	snapshotPath := path.Join(fakeSnapshotsPath, "seed")
+
+	mockPc.EXPECT().IsDir(snapshotPath).Return(true)
+	mockPc.EXPECT().ReadDir(snapshotPath).Return([]os.FileInfo{
+		fakeFileInfo{name: "ensi-test_db.tar.gz"},
+	}, nil)
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "volume", "ls", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi-test_db\n", nil)
+
+	expectServiceRunning(mockPc, true)
+	expectStopService(mockPc, composeFilePath)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "run", "--rm", "-v", "ensi-test_db:/volume", "-v", snapshotPath + ":/snapshot:ro",
+			"alpine:3", "sh", "-c", "find /volume -mindepth 1 -delete && tar -xzf /snapshot/ensi-test_db.tar.gz -C /volume"}, gomock.Any()).
+		Return(1, "", errors.New("exit status 1"))
+
+	// service must be started again after failed restore
+	mockPc.EXPECT().FileExists(gomock.Any()).Return(true)
+	expectServiceRunning(mockPc, false)
+	mockPc.EXPECT().
+		ExecInteractive([]string{"docker", "compose", "-f", composeFilePath, "up", "-d"}, gomock.Any()).
+		Return(0, nil)
+
+	err := SnapshotRestoreAction("test", "seed", &core.GlobalOptions{})
+	if err == nil || err.Error() != "can not restore volume ensi-test_db: exit status 1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
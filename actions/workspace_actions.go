@@ -1,47 +1,61 @@
 package actions
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/ensi-platform/elc/core"
+	"strings"
+	"text/tabwriter"
 )
 
-func ListWorkspacesAction() error {
+func ListWorkspacesAction(options *core.GlobalOptions) error {
 	hc, err := core.CheckAndLoadHC()
 	if err != nil {
 		return err
 	}
-	for _, workspace := range hc.Workspaces {
-		_, _ = core.Pc.Printf("%-10s %s\n", workspace.Name, workspace.Path)
-	}
-	return nil
+
+	return core.PrintOutput(options.Output, hc.WorkspacesInfo(options.WorkspaceName), func() {
+		for _, workspace := range hc.Workspaces {
+			_, _ = core.Pc.Printf("%-10s %s\n", workspace.Name, workspace.Path)
+		}
+	})
 }
 
 func AddWorkspaceAction(name string, wsPath string) error {
-	hc, err := core.CheckAndLoadHC()
+	err := core.CheckWorkspaceName(name)
 	if err != nil {
 		return err
 	}
-
-	ws := hc.FindWorkspace(name)
-	if ws != nil {
-		return errors.New(fmt.Sprintf("workspace with name '%s' already exists", name))
+	wsPath, err = core.NormalizePath(wsPath)
+	if err != nil {
+		return err
 	}
-
-	err = hc.AddWorkspace(name, wsPath)
+	err = core.CheckWorkspacePath(wsPath)
 	if err != nil {
 		return err
 	}
 
-	_, _ = core.Pc.Printf("workspace '%s' is added\n", name)
-
-	if hc.CurrentWorkspace == "" {
-		hc.CurrentWorkspace = name
-		err = core.SaveHomeConfig(hc)
+	selected := false
+	err = core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		err := hc.AddWorkspace(name, wsPath)
 		if err != nil {
 			return err
 		}
 
+		if hc.CurrentWorkspace == "" {
+			hc.CurrentWorkspace = name
+			selected = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = core.Pc.Printf("workspace '%s' is added\n", name)
+	if selected {
 		_, _ = core.Pc.Printf("active workspace changed to '%s'\n", name)
 	}
 
@@ -49,72 +63,221 @@ func AddWorkspaceAction(name string, wsPath string) error {
 }
 
 func RemoveWorkspaceAction(name string) error {
-	hc, err := core.CheckAndLoadHC()
+	err := core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		return hc.RemoveWorkspace(name)
+	})
 	if err != nil {
 		return err
 	}
 
 	_, _ = core.Pc.Printf("workspace '%s' is removed\n", name)
 
-	return hc.RemoveWorkspace(name)
+	return nil
 }
 
 func ShowCurrentWorkspaceAction(options *core.GlobalOptions) error {
-	hc, err := core.CheckAndLoadHC()
+	cwd, err := core.Pc.Getwd()
 	if err != nil {
 		return err
 	}
-	hci, err := hc.GetCurrentWorkspace(options.WorkspaceName)
+	_, hci, err := core.ResolveWorkspace(options.WorkspaceName, cwd)
 	if err != nil {
 		return err
 	}
 
-	_, _ = core.Pc.Println(hci.Name)
-	return nil
+	return core.PrintOutput(options.Output, hci.Info(true), func() {
+		if hci.Name == "" {
+			// workspace is found in current directory, but it is not registered
+			_, _ = core.Pc.Println(hci.Path)
+		} else {
+			_, _ = core.Pc.Println(hci.Name)
+		}
+	})
 }
 
 func SelectWorkspaceAction(name string) error {
-	hc, err := core.CheckAndLoadHC()
+	err := core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		if name != "auto" {
+			ws := hc.FindWorkspace(name)
+			if ws == nil {
+				return errors.New(fmt.Sprintf("workspace with name '%s' is not defined", name))
+			}
+		}
+
+		hc.CurrentWorkspace = name
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	if name != "auto" {
+	_, _ = core.Pc.Printf("active workspace changed to '%s'\n", name)
+
+	return nil
+}
+
+func SetRootPathAction(name string, rootPath string) error {
+	rootPath, err := core.NormalizePath(rootPath)
+	if err != nil {
+		return err
+	}
+	if !core.Pc.FileExists(rootPath) {
+		return errors.New(fmt.Sprintf("root path %s does not exist", rootPath))
+	}
+
+	err = core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
 		ws := hc.FindWorkspace(name)
 		if ws == nil {
 			return errors.New(fmt.Sprintf("workspace with name '%s' is not defined", name))
 		}
+
+		ws.RootPath = rootPath
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	hc.CurrentWorkspace = name
-	err = core.SaveHomeConfig(hc)
+	_, _ = core.Pc.Printf("path saved\n")
+
+	return nil
+}
+
+func RenameWorkspaceAction(name string, newName string) error {
+	err := core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		return hc.RenameWorkspace(name, newName)
+	})
 	if err != nil {
 		return err
 	}
 
-	_, _ = core.Pc.Printf("active workspace changed to '%s'\n", name)
+	_, _ = core.Pc.Printf("workspace '%s' is renamed to '%s'\n", name, newName)
 
 	return nil
 }
 
-func SetRootPathAction(name string, rootPath string) error {
-	hc, err := core.CheckAndLoadHC()
+func MoveWorkspaceAction(name string, wsPath string, rootPath string) error {
+	wsPath, err := core.NormalizePath(wsPath)
+	if err != nil {
+		return err
+	}
+	err = core.CheckWorkspacePath(wsPath)
 	if err != nil {
 		return err
 	}
+	if rootPath != "" {
+		rootPath, err = core.NormalizePath(rootPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var hci core.HomeConfigItem
+	err = core.UpdateHomeConfig(func(hc *core.HomeConfig) error {
+		err := hc.MoveWorkspace(name, wsPath, rootPath)
+		if err != nil {
+			return err
+		}
+		hci = *hc.FindWorkspace(name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = core.Pc.Printf("workspace '%s' is moved to %s\n", name, hci.Path)
+	if hci.RootPath != "" {
+		_, _ = core.Pc.Printf("root path is %s\n", hci.RootPath)
+	}
+
+	return nil
+}
 
-	ws := hc.FindWorkspace(name)
-	if ws == nil {
-		return errors.New(fmt.Sprintf("workspace with name '%s' is not defined", name))
+func WorkspaceDoctorAction(options *core.GlobalOptions) error {
+	hc, err := core.CheckAndLoadHC()
+	if err != nil {
+		return err
 	}
 
-	ws.RootPath = rootPath
-	err = core.SaveHomeConfig(hc)
+	problems := hc.Problems()
+	err = core.PrintOutput(options.Output, problems, func() {
+		for _, problem := range problems {
+			_, _ = core.Pc.Printf("%s: %s\n", problem.Workspace, problem.Problem)
+		}
+		if len(problems) == 0 {
+			_, _ = core.Pc.Println("no problems found")
+		}
+	})
 	if err != nil {
 		return err
 	}
 
-	_, _ = core.Pc.Printf("path saved\n")
+	if len(problems) > 0 {
+		return errors.New(fmt.Sprintf("found %d problems in %s", len(problems), hc.Path))
+	}
 
 	return nil
 }
+
+// DestroyWorkspaceAction destroys containers of all services of workspace and removes its networks.
+func DestroyWorkspaceAction(options *core.GlobalOptions) error {
+	return forEachWorkspace(options, func(ws *core.Workspace) error {
+		err := stopComponents(ws, ws.GetComponentNames(), true, options)
+		if err != nil {
+			return err
+		}
+
+		return ws.RemoveNetworks(options)
+	})
+}
+
+func WorkspaceStatusAction(options *core.GlobalOptions) error {
+	workspaces, err := core.LoadRegisteredWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	status := core.WorkspacesStatus{Workspaces: make([]core.WorkspaceStatus, 0)}
+	for _, rw := range workspaces {
+		wsStatus := core.WorkspaceStatus{Name: rw.Name, Path: rw.Path, Running: make([]string, 0)}
+		err := rw.Error
+		if err == nil {
+			wsStatus.Running, err = rw.Workspace.FindComponentNames(&core.ComponentFilter{Running: true}, options)
+		}
+		if err != nil {
+			wsStatus.Error = err.Error()
+		}
+		status.Workspaces = append(status.Workspaces, wsStatus)
+	}
+
+	status.Collisions, err = core.FindWorkspaceCollisions(workspaces)
+	if err != nil {
+		return err
+	}
+
+	return core.PrintOutput(options.Output, status, func() {
+		printWorkspacesStatus(status)
+	})
+}
+
+func printWorkspacesStatus(status core.WorkspacesStatus) {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WORKSPACE\tPATH\tRUNNING")
+	for _, wsStatus := range status.Workspaces {
+		running := strings.Join(wsStatus.Running, ",")
+		if wsStatus.Error != "" {
+			running = "error: " + wsStatus.Error
+		} else if running == "" {
+			running = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", wsStatus.Name, wsStatus.Path, running)
+	}
+	_ = w.Flush()
+
+	for _, collision := range status.Collisions {
+		_, _ = fmt.Fprintf(&buf, "collision of %s %s: %s\n", collision.Kind, collision.Value, strings.Join(collision.Components, ", "))
+	}
+
+	_, _ = core.Pc.Printf("%s", buf.String())
+}
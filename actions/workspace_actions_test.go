@@ -1,13 +1,20 @@
 package actions
 
 import (
+	"fmt"
 	"github.com/ensi-platform/elc/core"
+	"github.com/golang/mock/gomock"
+	"io"
 	"os"
+	"path"
+	"strings"
 	"testing"
 )
 
 func TestWorkspaceShow(t *testing.T) {
 	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/home", nil)
+	expectNoLocalWorkspace(mockPc, "/tmp/home")
 	expectReadHomeConfig(mockPc)
 
 	mockPc.EXPECT().Println("project1")
@@ -22,15 +29,26 @@ func TestWorkspaceList(t *testing.T) {
 	mockPc.EXPECT().Printf("%-10s %s\n", "project1", "/tmp/workspaces/project1")
 	mockPc.EXPECT().Printf("%-10s %s\n", "project2", "/tmp/workspaces/project2")
 
-	_ = ListWorkspacesAction()
+	_ = ListWorkspacesAction(&core.GlobalOptions{})
+}
+
+func expectUpdateHomeConfig(mockPc *core.MockPC, previous string, expected string) {
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().LockFile(fakeHomeConfigPath+".lock").Return(io.NopCloser(strings.NewReader("")), nil)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(true).Times(2)
+	mockPc.EXPECT().ReadFile(fakeHomeConfigPath).Return([]byte(previous), nil).Times(2)
+	gomock.InOrder(
+		mockPc.EXPECT().WriteFile(fakeHomeConfigPath+".bak", []byte(previous), os.FileMode(0644)).Return(nil),
+		mockPc.EXPECT().WriteFile(fakeHomeConfigPath+".tmp", []byte(expected), os.FileMode(0644)).Return(nil),
+		mockPc.EXPECT().Rename(fakeHomeConfigPath+".tmp", fakeHomeConfigPath).Return(nil),
+	)
 }
 
 func TestWorkspaceAdd(t *testing.T) {
 	mockPc := setupMockPc(t)
-	expectReadHomeConfig(mockPc)
 
-	const homeConfigForAdd = `current_workspace: project1
-update_command: update
+	const homeConfigForAdd = `version: 1
+current_workspace: project1
 workspaces:
 - name: project1
   path: /tmp/workspaces/project1
@@ -43,18 +61,21 @@ workspaces:
   root_path: ""
 `
 
-	mockPc.EXPECT().WriteFile(fakeHomeConfigPath, []byte(homeConfigForAdd), os.FileMode(0644))
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project3/workspace.yaml").Return(true)
+	expectUpdateHomeConfig(mockPc, baseHomeConfig, homeConfigForAdd)
 	mockPc.EXPECT().Printf("workspace '%s' is added\n", "project3")
 
-	_ = AddWorkspaceAction("project3", "/tmp/workspaces/project3")
+	err := AddWorkspaceAction("project3", "/tmp/workspaces/project3")
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestWorkspaceSelect(t *testing.T) {
 	mockPc := setupMockPc(t)
-	expectReadHomeConfig(mockPc)
 
-	const homeConfigForSelect = `current_workspace: project2
-update_command: update
+	const homeConfigForSelect = `version: 1
+current_workspace: project2
 workspaces:
 - name: project1
   path: /tmp/workspaces/project1
@@ -64,8 +85,517 @@ workspaces:
   root_path: ""
 `
 
-	mockPc.EXPECT().WriteFile(fakeHomeConfigPath, []byte(homeConfigForSelect), os.FileMode(0644))
+	expectUpdateHomeConfig(mockPc, baseHomeConfig, homeConfigForSelect)
 	mockPc.EXPECT().Printf("active workspace changed to '%s'\n", "project2")
 
-	_ = SelectWorkspaceAction("project2")
+	err := SelectWorkspaceAction("project2")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceAddExisting(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/other/workspace.yaml").Return(true)
+	mockPc.EXPECT().LockFile(fakeHomeConfigPath+".lock").Return(io.NopCloser(strings.NewReader("")), nil)
+	expectReadHomeConfig(mockPc)
+
+	err := AddWorkspaceAction("project2", "/tmp/workspaces/other")
+	if err == nil || err.Error() != "workspace with name 'project2' already exists" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceAddCreatesHomeConfig(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/workspace.yaml").Return(true)
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().LockFile(fakeHomeConfigPath+".lock").Return(io.NopCloser(strings.NewReader("")), nil)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(false).Times(2)
+	gomock.InOrder(
+		mockPc.EXPECT().WriteFile(fakeHomeConfigPath+".tmp", []byte(`version: 1
+current_workspace: project1
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1
+  root_path: ""
+`), os.FileMode(0644)).Return(nil),
+		mockPc.EXPECT().Rename(fakeHomeConfigPath+".tmp", fakeHomeConfigPath).Return(nil),
+	)
+	mockPc.EXPECT().Printf("workspace '%s' is added\n", "project1")
+	mockPc.EXPECT().Printf("active workspace changed to '%s'\n", "project1")
+
+	err := AddWorkspaceAction("project1", "/tmp/workspaces/project1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceListNewerFormat(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, "version: 99\nworkspaces: []\n")
+
+	err := ListWorkspacesAction(&core.GlobalOptions{})
+	if err == nil || err.Error() != "/tmp/home/.elc.yaml has format version 99, but this elc supports version 1, please update elc" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceListBrokenConfig(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, "workspaces: [")
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath + ".bak").Return(true)
+
+	err := ListWorkspacesAction(&core.GlobalOptions{})
+	if err == nil || !strings.Contains(err.Error(), "previous version is saved in /tmp/home/.elc.yaml.bak") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+const workspaceConfigWithVersion = `
+name: ensi
+elc_version: "~> 2.0"
+services:
+  test:
+    path: "${WORKSPACE_PATH}/apps/test"
+`
+
+func setVersion(t *testing.T, version string) {
+	prevVersion := core.Version
+	core.Version = version
+	t.Cleanup(func() {
+		core.Version = prevVersion
+	})
+}
+
+// expectReadVersionedWorkspaceConfig expects reading of workspace with elc_version, version is checked before context of workspace is created
+func expectReadVersionedWorkspaceConfig(mockPc *core.MockPC) {
+	cwd := path.Join(fakeWorkspacePath, "apps/test")
+	mockPc.EXPECT().Getwd().Return(cwd, nil)
+	expectNoLocalWorkspace(mockPc, cwd)
+	expectWorkspaceConfigFiles(mockPc, fakeWorkspacePath, workspaceConfigWithVersion, "")
+}
+
+func TestWorkspaceVersionSwitchToCachedBinary(t *testing.T) {
+	mockPc := setupMockPc(t)
+	setVersion(t, "1.0.0")
+	expectReadHomeConfig(mockPc)
+	expectReadVersionedWorkspaceConfig(mockPc)
+
+	versionsDir := "/tmp/home/.elc/versions"
+	mockPc.EXPECT().LookupEnv("ELC_VERSION_SWITCHED").Return("", false)
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().FileExists(versionsDir).Return(true)
+	mockPc.EXPECT().ReadDir(versionsDir).Return([]os.FileInfo{
+		fakeFileInfo{name: "elc-v1.9.0"},
+		fakeFileInfo{name: "elc-v2.0.1"},
+		fakeFileInfo{name: "elc-v2.3.0"},
+		fakeFileInfo{name: "elc-v3.0.0"},
+	}, nil)
+	mockPc.EXPECT().Args().Return([]string{"elc", "start", "--mode=hook"})
+	mockPc.EXPECT().
+		ExecInteractive([]string{path.Join(versionsDir, "elc-v2.3.0"), "start", "--mode=hook"}, []string{"ELC_VERSION_SWITCHED=1"}).
+		Return(3, nil)
+	mockPc.EXPECT().Exit(3)
+
+	_ = StartServiceAction(&core.GlobalOptions{}, []string{})
+}
+
+func TestWorkspaceVersionSwitchDownloadsBinary(t *testing.T) {
+	mockPc := setupMockPc(t)
+	setVersion(t, "1.0.0")
+	binary := []byte("new binary")
+	server := startReleaseServer(t, binary, sha256String(binary), "")
+
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil).Times(2)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(true)
+	mockPc.EXPECT().ReadFile(fakeHomeConfigPath).Return([]byte(baseHomeConfig+"update_mirror: "+server.URL+"\n"), nil)
+	expectReadVersionedWorkspaceConfig(mockPc)
+
+	versionsDir := "/tmp/home/.elc/versions"
+	mockPc.EXPECT().LookupEnv("ELC_VERSION_SWITCHED").Return("", false)
+	mockPc.EXPECT().FileExists(versionsDir).Return(false).Times(2)
+	mockPc.EXPECT().FileExists("/tmp/home/.elc").Return(true)
+	mockPc.EXPECT().CreateDir(versionsDir).Return(nil)
+	mockPc.EXPECT().Printf("This workspace requires elc version %s, downloading it\n", "~> 2.0")
+	mockPc.EXPECT().Printf("Downloading %s\n", gomock.Any())
+	mockPc.EXPECT().WriteFile(path.Join(versionsDir, "elc-v2.0.0"), binary, gomock.Any()).Return(nil)
+	mockPc.EXPECT().Chmod(path.Join(versionsDir, "elc-v2.0.0"), gomock.Any()).Return(nil)
+	mockPc.EXPECT().Args().Return([]string{"elc", "vars"})
+	mockPc.EXPECT().
+		ExecInteractive([]string{path.Join(versionsDir, "elc-v2.0.0"), "vars"}, gomock.Any()).
+		Return(0, nil)
+	mockPc.EXPECT().Exit(0)
+
+	_ = PrintVarsAction(&core.GlobalOptions{}, []string{}, false)
+}
+
+func TestWorkspaceVersionAlreadySwitched(t *testing.T) {
+	mockPc := setupMockPc(t)
+	setVersion(t, "1.0.0")
+	expectReadHomeConfig(mockPc)
+	expectReadVersionedWorkspaceConfig(mockPc)
+
+	mockPc.EXPECT().LookupEnv("ELC_VERSION_SWITCHED").Return("1", true)
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err == nil || err.Error() != "This workspace requires elc version ~> 2.0, but binary of version 1.0.0 is started." {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceListJson(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+
+	mockPc.EXPECT().Println(`[
+  {
+    "name": "project1",
+    "path": "/tmp/workspaces/project1",
+    "root_path": "",
+    "current": true
+  },
+  {
+    "name": "project2",
+    "path": "/tmp/workspaces/project2",
+    "root_path": "",
+    "current": false
+  }
+]`)
+
+	err := ListWorkspacesAction(&core.GlobalOptions{Output: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceShowYaml(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/home", nil)
+	expectNoLocalWorkspace(mockPc, "/tmp/home")
+	expectReadHomeConfig(mockPc)
+
+	mockPc.EXPECT().Println(`name: project1
+path: /tmp/workspaces/project1
+root_path: ""
+current: true`)
+
+	err := ShowCurrentWorkspaceAction(&core.GlobalOptions{Output: "yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectLocalWorkspace(mockPc *core.MockPC, cwd string, wsPath string) {
+	dir := cwd
+	for ; dir != wsPath; dir = path.Dir(dir) {
+		mockPc.EXPECT().FileExists(path.Join(dir, "workspace.yaml")).Return(false)
+		mockPc.EXPECT().FileExists(path.Join(dir, ".elc")).Return(false)
+	}
+	mockPc.EXPECT().FileExists(path.Join(wsPath, "workspace.yaml")).Return(true)
+}
+
+func TestWorkspaceShowLocal(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/checkout/apps/test", nil)
+	expectLocalWorkspace(mockPc, "/tmp/checkout/apps/test", "/tmp/checkout")
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(false)
+
+	mockPc.EXPECT().Println("/tmp/checkout")
+
+	err := ShowCurrentWorkspaceAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceShowByMarker(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/workspaces/apps", nil)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/apps/workspace.yaml").Return(false)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/apps/.elc").Return(false)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/workspace.yaml").Return(false)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/.elc").Return(true)
+	mockPc.EXPECT().IsDir("/tmp/workspaces/.elc").Return(false)
+	mockPc.EXPECT().ReadFile("/tmp/workspaces/.elc").Return([]byte("project1/workspace.yaml\n"), nil)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/workspace.yaml").Return(true)
+	expectReadHomeConfig(mockPc)
+
+	mockPc.EXPECT().Println("project1")
+
+	err := ShowCurrentWorkspaceAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceShowSkipsHomeElcDir(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/home/projects", nil)
+	mockPc.EXPECT().FileExists("/tmp/home/.elc").Return(true)
+	mockPc.EXPECT().IsDir("/tmp/home/.elc").Return(true)
+	expectNoLocalWorkspace(mockPc, "/tmp/home/projects")
+	expectReadHomeConfig(mockPc)
+
+	mockPc.EXPECT().Println("project1")
+
+	err := ShowCurrentWorkspaceAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartInLocalWorkspace(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/checkout/apps/test", nil)
+	expectLocalWorkspace(mockPc, "/tmp/checkout/apps/test", "/tmp/checkout")
+	mockPc.EXPECT().HomeDir().Return("/tmp/home", nil)
+	mockPc.EXPECT().FileExists(fakeHomeConfigPath).Return(false)
+	expectWorkspaceFiles(mockPc, "/tmp/checkout", workspaceConfig, "")
+
+	expectStartService(mockPc, "/tmp/checkout/apps/test/docker-compose.yml")
+
+	err := StartServiceAction(&core.GlobalOptions{}, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceStartWorkspaceFlagOverridesLocal(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/checkout/apps/test", nil)
+	expectReadHomeConfig(mockPc)
+	expectWorkspaceFiles(mockPc, "/tmp/workspaces/project2", workspaceConfig, "")
+
+	expectStartService(mockPc, "/tmp/workspaces/project2/apps/test/docker-compose.yml")
+
+	err := StartServiceAction(&core.GlobalOptions{WorkspaceName: "project2"}, []string{"test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceAddRelativePath(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().Getwd().Return("/tmp/workspaces", nil)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project3/workspace.yaml").Return(false)
+
+	err := AddWorkspaceAction("project3", "./project3/")
+	if err == nil || err.Error() != "workspace.yaml is not found in /tmp/workspaces/project3" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceAddReservedName(t *testing.T) {
+	setupMockPc(t)
+
+	err := AddWorkspaceAction("auto", "/tmp/workspaces/project3")
+	if err == nil || err.Error() != "name 'auto' is reserved for automatic selection of workspace" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceRemoveUnknown(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().LockFile(fakeHomeConfigPath+".lock").Return(io.NopCloser(strings.NewReader("")), nil)
+	expectReadHomeConfig(mockPc)
+
+	err := RemoveWorkspaceAction("project3")
+	if err == nil || err.Error() != "Workspace project3 doesn't exists" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceRename(t *testing.T) {
+	mockPc := setupMockPc(t)
+
+	expectUpdateHomeConfig(mockPc, baseHomeConfig, `version: 1
+current_workspace: main
+workspaces:
+- name: main
+  path: /tmp/workspaces/project1
+  root_path: ""
+- name: project2
+  path: /tmp/workspaces/project2
+  root_path: ""
+`)
+	mockPc.EXPECT().Printf("workspace '%s' is renamed to '%s'\n", "project1", "main")
+
+	err := RenameWorkspaceAction("project1", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const homeConfigWithRoot = `
+current_workspace: project1
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1/workspace
+  root_path: /tmp/workspaces/project1
+`
+
+func TestWorkspaceMoveWithRoot(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().FileExists("/tmp/new/project1/workspace/workspace.yaml").Return(true)
+
+	expectUpdateHomeConfig(mockPc, homeConfigWithRoot, `version: 1
+current_workspace: project1
+workspaces:
+- name: project1
+  path: /tmp/new/project1/workspace
+  root_path: /tmp/new/project1
+`)
+	mockPc.EXPECT().Printf("workspace '%s' is moved to %s\n", "project1", "/tmp/new/project1/workspace")
+	mockPc.EXPECT().Printf("root path is %s\n", "/tmp/new/project1")
+
+	err := MoveWorkspaceAction("project1", "/tmp/new/project1/workspace/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceMoveRootNotComputed(t *testing.T) {
+	mockPc := setupMockPc(t)
+	mockPc.EXPECT().FileExists("/tmp/new/ws/workspace.yaml").Return(true)
+	mockPc.EXPECT().LockFile(fakeHomeConfigPath+".lock").Return(io.NopCloser(strings.NewReader("")), nil)
+	expectReadCustomHomeConfig(mockPc, homeConfigWithRoot)
+
+	err := MoveWorkspaceAction("project1", "/tmp/new/ws", "")
+	if err == nil || err.Error() != "can not move root path /tmp/workspaces/project1 together with workspace, pass new root path explicitly" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceDoctor(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadCustomHomeConfig(mockPc, `
+current_workspace: removed
+workspaces:
+- name: project1
+  path: /tmp/workspaces/project1
+  root_path: /tmp/projects/project1
+- name: project2
+  path: workspaces/project2
+  root_path: ""
+- name: project1
+  path: /tmp/workspaces/project1
+  root_path: ""
+`)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/workspace.yaml").Return(true).Times(2)
+	mockPc.EXPECT().FileExists("/tmp/projects/project1").Return(false)
+
+	gomock.InOrder(
+		mockPc.EXPECT().Printf("%s: %s\n", "project1", "root path /tmp/projects/project1 does not exist"),
+		mockPc.EXPECT().Printf("%s: %s\n", "project2", "path workspaces/project2 is not absolute"),
+		mockPc.EXPECT().Printf("%s: %s\n", "project1", "name is registered more than once"),
+		mockPc.EXPECT().Printf("%s: %s\n", "project1", "path /tmp/workspaces/project1 is registered by workspace 'project1' too"),
+		mockPc.EXPECT().Printf("%s: %s\n", "removed", "current workspace 'removed' is not registered"),
+	)
+
+	err := WorkspaceDoctorAction(&core.GlobalOptions{})
+	if err == nil || err.Error() != "found 5 problems in /tmp/home/.elc.yaml" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWorkspaceDoctorOk(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/workspace.yaml").Return(true)
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project2/workspace.yaml").Return(true)
+	mockPc.EXPECT().Println("no problems found")
+
+	err := WorkspaceDoctorAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const workspaceConfigForStatus = `
+name: ensi
+services:
+  backend:
+    path: "${WORKSPACE_PATH}/apps/backend"
+`
+
+const composeForStatus1 = `
+services:
+  app:
+    ports:
+      - "${HTTP_PORT:-8080}:80"
+      - 9000
+      - target: 443
+        published: 8443
+networks:
+  shared:
+    name: ensi-net
+  proxy:
+    name: proxy
+    external: true
+`
+
+const composeForStatus2 = `
+services:
+  app:
+    ports:
+      - "8081:80"
+      - "127.0.0.1:8443:443/tcp"
+networks:
+  shared:
+    name: ensi-net
+  proxy:
+    name: proxy
+    external: true
+`
+
+func TestWorkspaceStatus(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadAllWorkspaces(mockPc, workspaceConfigForStatus, workspaceConfigForStatus)
+
+	expectDockerPs(mockPc, "ensi-backend\n")
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project1/apps/backend").Return(true)
+	expectDockerPs(mockPc, "")
+	mockPc.EXPECT().FileExists("/tmp/workspaces/project2/apps/backend").Return(true)
+
+	for index, compose := range []string{composeForStatus1, composeForStatus2} {
+		composePath := fmt.Sprintf("/tmp/workspaces/project%d/apps/backend/docker-compose.yml", index+1)
+		mockPc.EXPECT().FileExists(composePath).Return(true)
+		mockPc.EXPECT().ReadFile(composePath).Return([]byte(compose), nil)
+	}
+
+	mockPc.EXPECT().Printf("%s", ""+
+		"WORKSPACE  PATH                      RUNNING\n"+
+		"project1   /tmp/workspaces/project1  backend\n"+
+		"project2   /tmp/workspaces/project2  -\n"+
+		"collision of network ensi-net: project1/backend, project2/backend\n"+
+		"collision of port 8443: project1/backend, project2/backend\n"+
+		"collision of project ensi-backend: project1/backend, project2/backend\n")
+
+	err := WorkspaceStatusAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceDestroy(t *testing.T) {
+	mockPc := setupMockPc(t)
+	expectReadHomeConfig(mockPc)
+	expectReadWorkspaceConfig(mockPc, fakeWorkspacePath, workspaceConfigWithNetworks, "")
+
+	expectDestroyService(mockPc, path.Join(fakeWorkspacePath, "apps/test/docker-compose.yml"))
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "ls", "--filter", "label=elc.workspace=ensi", "--format", "{{.Name}}"}, gomock.Any()).
+		Return(0, "ensi\n", nil)
+
+	mockPc.EXPECT().
+		ExecToString([]string{"docker", "network", "rm", "ensi"}, gomock.Any()).
+		Return(0, "", nil)
+
+	err := DestroyWorkspaceAction(&core.GlobalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
 }
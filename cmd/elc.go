@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ensi-platform/elc/actions"
 	"github.com/ensi-platform/elc/core"
 	"github.com/spf13/cobra"
 	"os"
+	"sort"
 )
 
 var globalOptions core.GlobalOptions
 
 func parseStartFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&globalOptions.Force, "force", false, "force start dependencies, even if service already started")
-	cmd.Flags().StringVar(&globalOptions.Mode, "mode", "default", "start only dependencies with specified mode, by default starts 'default' dependencies")
+	cmd.Flags().StringVar(&globalOptions.Mode, "mode", "", "start only dependencies with specified mode, by default starts dependencies of mode from ~/.elc.yaml or 'default'")
 }
 
 func parseExecFlags(cmd *cobra.Command) {
@@ -19,6 +22,46 @@ func parseExecFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&globalOptions.NoTty, "no-tty", false, "disable pseudo-TTY allocation")
 }
 
+// applyDefaultFlags sets flags of command from defaults of ~/.elc.yaml, flags passed explicitly are not changed.
+// Broken ~/.elc.yaml does not stop the command, it runs without defaults, commands which need the config report the error.
+func applyDefaultFlags(cmd *cobra.Command) error {
+	commandName := core.DefaultFlagsCommand(cmd.CommandPath())
+	defaults, err := core.DefaultFlags(commandName)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: default flags from ~/.elc.yaml are not applied: %s\n", err)
+		return nil
+	}
+
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if cmd.Flags().Lookup(name) == nil {
+			return errors.New(fmt.Sprintf("unknown flag '%s' in defaults of command '%s' in ~/.elc.yaml", name, commandName))
+		}
+		if cmd.Flags().Changed(name) {
+			continue
+		}
+		err = cmd.Flags().Set(name, defaults[name])
+		if err != nil {
+			return errors.New(fmt.Sprintf("bad default value of flag '%s' of command '%s': %s", name, commandName, err))
+		}
+	}
+
+	return nil
+}
+
+// commands with this annotation support --all-workspaces
+const allWorkspacesAnnotation = "all-workspaces"
+
+var allWorkspacesSupported = map[string]string{allWorkspacesAnnotation: "true"}
+
+// commands with this annotation ignore defaults of ~/.elc.yaml, doctor must work with broken config to report it
+const noDefaultFlagsAnnotation = "no-default-flags"
+
 func InitCobra() *cobra.Command {
 	globalOptions = core.GlobalOptions{}
 	var rootCmd = &cobra.Command{
@@ -27,8 +70,22 @@ func InitCobra() *cobra.Command {
 		SilenceUsage: true,
 		//SilenceErrors: true,
 		Version: core.Version,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			core.Pc = &core.RealPC{}
+			if cmd.Annotations[noDefaultFlagsAnnotation] == "" {
+				err := applyDefaultFlags(cmd)
+				if err != nil {
+					return err
+				}
+			}
+			if globalOptions.AllWorkspaces && cmd.Annotations[allWorkspacesAnnotation] == "" {
+				return errors.New(fmt.Sprintf("--all-workspaces is not supported by command '%s'", cmd.CommandPath()))
+			}
+			err := globalOptions.Filter.Validate()
+			if err != nil {
+				return err
+			}
+			return core.CheckOutputFormat(globalOptions.Output)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -42,12 +99,21 @@ func InitCobra() *cobra.Command {
 
 	rootCmd.Flags().SetInterspersed(false)
 
-	rootCmd.PersistentFlags().StringVarP(&globalOptions.ComponentName, "component", "c", "", "name of component")
+	rootCmd.PersistentFlags().StringVarP(&globalOptions.ComponentName, "component", "c", "", "name of component or selector expression")
 	rootCmd.PersistentFlags().StringVarP(&globalOptions.WorkspaceName, "workspace", "w", "", "name of workspace")
+	rootCmd.PersistentFlags().BoolVar(&globalOptions.AllWorkspaces, "all-workspaces", false, "run command in every registered workspace, supported by start, stop, destroy, prune, restart, clone, pull and workspace destroy")
 	rootCmd.PersistentFlags().StringVar(&globalOptions.ComponentName, "svc", "", "name of current component (deprecated, alias for component)")
 	rootCmd.PersistentFlags().BoolVar(&globalOptions.Debug, "debug", false, "print debug messages")
 	rootCmd.PersistentFlags().BoolVar(&globalOptions.DryRun, "dry-run", false, "do not execute real command, only debug")
-	rootCmd.PersistentFlags().StringVar(&globalOptions.Tag, "tag", "", "select all components with tag")
+	rootCmd.PersistentFlags().StringSliceVar(&globalOptions.Filter.Tags, "tag", nil, "select all components with any of tags")
+	rootCmd.PersistentFlags().StringSliceVar(&globalOptions.Filter.NotTags, "not-tag", nil, "exclude components with tag")
+	rootCmd.PersistentFlags().StringVar(&globalOptions.Filter.Type, "type", "", "select components of type: service, module or template")
+	rootCmd.PersistentFlags().StringVar(&globalOptions.Filter.Extends, "extends", "", "select components extending template")
+	rootCmd.PersistentFlags().StringVar(&globalOptions.Filter.HostedIn, "hosted-in", "", "select modules hosted in component")
+	rootCmd.PersistentFlags().BoolVar(&globalOptions.Filter.Cloned, "cloned", false, "select only cloned components")
+	rootCmd.PersistentFlags().BoolVar(&globalOptions.Filter.Running, "running", false, "select only running components")
+	rootCmd.PersistentFlags().BoolVar(&globalOptions.CleanEnv, "clean-env", false, "pass only variables of component and PATH, HOME, DOCKER_* of host environment to child processes")
+	rootCmd.PersistentFlags().StringVarP(&globalOptions.Output, "output", "o", core.OutputText, "output format of listing commands: text, json or yaml")
 
 	parseStartFlags(rootCmd)
 	parseExecFlags(rootCmd)
@@ -56,6 +122,8 @@ func InitCobra() *cobra.Command {
 	NewServiceStartCommand(rootCmd)
 	NewServiceStopCommand(rootCmd)
 	NewServiceDestroyCommand(rootCmd)
+	NewPruneCommand(rootCmd)
+	NewSnapshotCommand(rootCmd)
 	NewServiceRestartCommand(rootCmd)
 	NewServiceVarsCommand(rootCmd)
 	NewServiceComposeCommand(rootCmd)
@@ -63,9 +131,12 @@ func InitCobra() *cobra.Command {
 	NewServiceExecCommand(rootCmd)
 	NewServiceRunCommand(rootCmd)
 	NewServiceSetHooksCommand(rootCmd)
+	NewHookCommand(rootCmd)
 	NewUpdateCommand(rootCmd)
 	NewFixUpdateCommand(rootCmd)
+	NewDoctorCommand(rootCmd)
 	NewServiceCloneCommand(rootCmd)
+	NewServicePullCommand(rootCmd)
 	NewServiceListCommand(rootCmd)
 
 	return rootCmd
@@ -82,6 +153,11 @@ func NewWorkspaceCommand(parentCommand *cobra.Command) {
 	NewWorkspaceShowCommand(command)
 	NewWorkspaceSelectCommand(command)
 	NewWorkspaceSetRootCommand(command)
+	NewWorkspaceRenameCommand(command)
+	NewWorkspaceMoveCommand(command)
+	NewWorkspaceStatusCommand(command)
+	NewWorkspaceDestroyCommand(command)
+	NewWorkspaceDoctorCommand(command)
 	parentCommand.AddCommand(command)
 }
 
@@ -92,7 +168,7 @@ func NewWorkspaceListCommand(parentCommand *cobra.Command) {
 		Short:   "Show list of registered workspaces",
 		Long:    "Show list of registered workspaces.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return actions.ListWorkspacesAction()
+			return actions.ListWorkspacesAction(&globalOptions)
 		},
 	}
 	parentCommand.AddCommand(command)
@@ -169,75 +245,249 @@ func NewWorkspaceSetRootCommand(parentCommand *cobra.Command) {
 	parentCommand.AddCommand(command)
 }
 
+func NewWorkspaceRenameCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "rename [NAME] [NEW_NAME]",
+		Short: "Rename registered workspace",
+		Long:  "Rename registered workspace, current workspace is updated too.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.RenameWorkspaceAction(args[0], args[1])
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+func NewWorkspaceMoveCommand(parentCommand *cobra.Command) {
+	var rootPath string
+	var command = &cobra.Command{
+		Use:   "move [NAME] [PATH]",
+		Short: "Change path of registered workspace",
+		Long:  "Change path of registered workspace.\nRoot path is moved together with workspace, unless it is passed with --root-path.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.MoveWorkspaceAction(args[0], args[1], rootPath)
+		},
+	}
+	command.Flags().StringVar(&rootPath, "root-path", "", "new root path of workspace")
+	parentCommand.AddCommand(command)
+}
+
+func NewWorkspaceStatusCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "status",
+		Short: "Show running components of every registered workspace",
+		Long:  "Show running components of every registered workspace and resources which can not be used by workspaces side by side:\nnames of compose projects, published ports and named networks.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.WorkspaceStatusAction(&globalOptions)
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+func NewWorkspaceDestroyCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "destroy",
+		Short: "Destroy containers of all services and networks of workspace",
+		Long:  "Stop and remove containers of all services of workspace, then remove networks from section 'networks' of workspace.yaml.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.DestroyWorkspaceAction(&globalOptions)
+		},
+	}
+	command.Annotations = allWorkspacesSupported
+	parentCommand.AddCommand(command)
+}
+
+func NewWorkspaceDoctorCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check registered workspaces",
+		Long:  "Check registered workspaces: paths must be absolute and contain workspace.yaml, names must be unique.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.WorkspaceDoctorAction(&globalOptions)
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+const selectorHelp = `Selector is a comma separated list of terms:
+  NAME          component name or alias
+  PATTERN       glob over component names, eg. 'catalog-*'
+  tag:TAG       components with tag
+  deps-of:NAME  all dependencies of component
+  all           all components except templates
+  !TERM         exclude components matched by term`
+
 func NewServiceStartCommand(parentCommand *cobra.Command) {
 	var command = &cobra.Command{
-		Use:   "start [OPTIONS] [NAME]",
+		Use:   "start [OPTIONS] [SELECTOR...]",
 		Short: "Start one or more services",
-		Long:  "Start one or more services.\nBy default starts service found with current directory, but you can pass one or more service names instead.",
+		Long:  "Start one or more services.\nBy default starts service found with current directory, but you can pass one or more service names or selectors instead.\n" + selectorHelp,
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return actions.StartServiceAction(&globalOptions, args)
 		},
 	}
 	parseStartFlags(command)
+	command.Annotations = allWorkspacesSupported
 	parentCommand.AddCommand(command)
 }
 
 func NewServiceStopCommand(parentCommand *cobra.Command) {
 	var stopAll bool
 	var command = &cobra.Command{
-		Use:   "stop [OPTIONS] [NAME]",
+		Use:   "stop [OPTIONS] [SELECTOR...]",
 		Short: "Stop one or more services",
-		Long:  "Stop one or more services.\nBy default stops service found with current directory, but you can pass one or more service names instead.",
+		Long:  "Stop one or more services.\nBy default stops service found with current directory, but you can pass one or more service names or selectors instead.\n" + selectorHelp,
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return actions.StopServiceAction(stopAll, args, false, &globalOptions)
 		},
 	}
 	command.Flags().BoolVar(&stopAll, "all", false, "stop all services")
+	command.Annotations = allWorkspacesSupported
 	parentCommand.AddCommand(command)
 }
 
 func NewServiceDestroyCommand(parentCommand *cobra.Command) {
 	var destroyAll bool
 	var command = &cobra.Command{
-		Use:   "destroy [OPTIONS] [NAME]",
+		Use:   "destroy [OPTIONS] [SELECTOR...]",
 		Short: "Stop and remove containers of one or more services",
-		Long:  "Stop and remove containers of one or more services.\nBy default destroys service found with current directory, but you can pass one or more service names instead.",
+		Long:  "Stop and remove containers of one or more services.\nBy default destroys service found with current directory, but you can pass one or more service names or selectors instead.\n" + selectorHelp,
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return actions.StopServiceAction(destroyAll, args, true, &globalOptions)
 		},
 	}
 	command.Flags().BoolVar(&destroyAll, "all", false, "destroy all services")
+	command.Annotations = allWorkspacesSupported
+	parentCommand.AddCommand(command)
+}
+
+func NewPruneCommand(parentCommand *cobra.Command) {
+	var pruneAll bool
+	var yes bool
+	var pruneOptions core.PruneOptions
+	var command = &cobra.Command{
+		Use:   "prune [OPTIONS] [SELECTOR...]",
+		Short: "Remove containers, volumes, images and networks of one or more services",
+		Long: "Remove containers of compose projects of one or more services, including orphan containers, and optionally their volumes, images and networks.\n" +
+			"Resources to remove are listed and confirmed before removal, with --dry-run they are only listed.\n" +
+			"By default prunes service found with current directory, but you can pass one or more service names or selectors instead.\n" + selectorHelp,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.PruneAction(pruneAll, yes, args, pruneOptions, &globalOptions)
+		},
+	}
+	command.Flags().BoolVar(&pruneAll, "all", false, "prune all services, with --networks networks of workspace are removed too")
+	command.Flags().BoolVar(&pruneOptions.Volumes, "volumes", false, "remove volumes")
+	command.Flags().BoolVar(&pruneOptions.Images, "images", false, "remove images built for services")
+	command.Flags().BoolVar(&pruneOptions.Networks, "networks", false, "remove networks")
+	command.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+	command.Annotations = allWorkspacesSupported
+	parentCommand.AddCommand(command)
+}
+
+func NewSnapshotCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save and restore named volumes of services",
+	}
+	NewSnapshotSaveCommand(command)
+	NewSnapshotRestoreCommand(command)
+	NewSnapshotListCommand(command)
+	NewSnapshotRemoveCommand(command)
+	parentCommand.AddCommand(command)
+}
+
+func NewSnapshotSaveCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "save [SERVICE] [NAME]",
+		Short: "Save named volumes of service",
+		Long:  "Stop service and archive its named volumes into ${WORKSPACE_PATH}/.elc/snapshots/SERVICE/NAME, running service is started again.\nName of snapshot defaults to current time.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var snapshotName string
+			if len(args) > 1 {
+				snapshotName = args[1]
+			}
+			return actions.SnapshotSaveAction(args[0], snapshotName, &globalOptions)
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+func NewSnapshotRestoreCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "restore [SERVICE] [NAME]",
+		Short: "Restore named volumes of service from snapshot",
+		Long:  "Stop service and replace content of its named volumes with saved snapshot, running service is started again.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.SnapshotRestoreAction(args[0], args[1], &globalOptions)
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+func NewSnapshotListCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:     "list [SERVICE...]",
+		Aliases: []string{"ls"},
+		Short:   "Show snapshots of services",
+		Long:    "Show saved snapshots of passed services or of all services of workspace.",
+		Args:    cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.SnapshotListAction(args, &globalOptions)
+		},
+	}
+	parentCommand.AddCommand(command)
+}
+
+func NewSnapshotRemoveCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "rm [SERVICE] [NAME]",
+		Short: "Remove snapshot of service",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.SnapshotRemoveAction(args[0], args[1], &globalOptions)
+		},
+	}
 	parentCommand.AddCommand(command)
 }
 
 func NewServiceRestartCommand(parentCommand *cobra.Command) {
 	var hardRestart bool
 	var command = &cobra.Command{
-		Use:   "restart [OPTIONS] [NAME]",
+		Use:   "restart [OPTIONS] [SELECTOR...]",
 		Short: "Restart one or more services",
-		Long:  "Restart one or more services.\nBy default restart service found with current directory, but you can pass one or more service names instead.",
+		Long:  "Restart one or more services.\nBy default restart service found with current directory, but you can pass one or more service names or selectors instead.\n" + selectorHelp,
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return actions.RestartServiceAction(hardRestart, args, &globalOptions)
 		},
 	}
 	command.Flags().BoolVar(&hardRestart, "hard", false, "destroy container instead of stop it before start")
+	command.Annotations = allWorkspacesSupported
 	parentCommand.AddCommand(command)
 }
 
 func NewServiceVarsCommand(parentCommand *cobra.Command) {
+	var exportDotenv bool
 	var command = &cobra.Command{
 		Use:   "vars [NAME]",
 		Short: "Print all variables computed for service",
 		Long:  "Print all variables computed for service.\nBy default uses service found with current directory, but you can pass name of another service instead.",
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return actions.PrintVarsAction(&globalOptions, args)
+			return actions.PrintVarsAction(&globalOptions, args, exportDotenv)
 		},
 	}
+	command.Flags().BoolVar(&exportDotenv, "export-dotenv", false, "print variables in .env format, e.g. for IDE")
 	parentCommand.AddCommand(command)
 }
 
@@ -313,37 +563,74 @@ func NewServiceRunCommand(parentCommand *cobra.Command) {
 }
 
 func NewServiceSetHooksCommand(parentCommand *cobra.Command) {
+	var uninstall bool
+	var check bool
 	var command = &cobra.Command{
-		Use:   "set-hooks [HOOKS_DIR]",
+		Use:   "set-hooks [OPTIONS] [HOOKS_DIR]",
 		Short: "Install hooks from specified folder to .git/hooks",
-		Long:  "Install hooks from specified folder to .git/hooks.\nHOOKS_PATH must contain subdirectories with names as git hooks, eg. 'pre-commit'.\nOne subdirectory can contain one or many scripts with .sh extension.\nEvery script will be wrapped with 'elc --tag=hook' command.",
+		Long:  "Install hooks from specified folder to .git/hooks.\nHOOKS_PATH must contain subdirectories with names as git hooks, eg. 'pre-commit'.\nOne subdirectory can contain one or many scripts with .sh extension.\nOnly hooks with at least one script are installed, wrappers of unused hooks are removed.\nExisting hooks which were not created by elc are renamed to HOOK_NAME.pre-elc and called before elc scripts.\nWithout HOOKS_DIR hooks are taken from 'hooks' section of component config in workspace.yaml.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scriptsFolder := ""
+			if len(args) > 0 {
+				scriptsFolder = args[0]
+			}
+			return actions.SetGitHooksAction(&globalOptions, scriptsFolder, os.Args[0], uninstall, check)
+		},
+	}
+	command.Flags().BoolVar(&uninstall, "uninstall", false, "remove hooks created by elc and restore previous hooks")
+	command.Flags().BoolVar(&check, "check", false, "check that installed hooks are up to date, exit with error otherwise")
+	parentCommand.AddCommand(command)
+}
+
+func NewHookCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "hook",
+		Short: "Work with git hooks of component",
+	}
+	NewHookRunCommand(command)
+	parentCommand.AddCommand(command)
+}
+
+func NewHookRunCommand(parentCommand *cobra.Command) {
+	var hooksDir string
+	var command = &cobra.Command{
+		Use:   "run [OPTIONS] [HOOK_NAME] [ARGS]",
+		Short: "Run scripts of git hook",
+		Long:  "Run scripts of git hook.\nScripts are taken from HOOKS_DIR/HOOK_NAME folder and executed in alphabetical order, execution stops on first failure.\nScripts with '.host.' in name are executed on host, others in container of component.\nWithout --hooks-dir commands from 'hooks' section of component config are executed in container, if they are defined.\nArguments and stdin of git hook are passed to every script.\nSet ELC_SKIP_HOOKS=1 to skip all hooks, or list hook and script names separated by comma.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return actions.SetGitHooksAction(&globalOptions, args[0], os.Args[0])
+			return actions.RunHookAction(&globalOptions, hooksDir, args[0], args[1:])
 		},
 	}
+	command.Flags().SetInterspersed(false)
+	command.Flags().StringVar(&hooksDir, "hooks-dir", "", "folder with hook scripts, relative to component root (default \".git_hooks\" if component has no hooks in config)")
 	parentCommand.AddCommand(command)
 }
 
 func NewUpdateCommand(parentCommand *cobra.Command) {
 	var version string
+	var targetPath string
+	var rollback bool
 	var command = &cobra.Command{
 		Use:   "update",
 		Short: "Update elc binary",
-		Long:  "Update elc binary.\nDownload new version of ELC, place it to /opt/elc/ and update symlink at /usr/local/bin.",
+		Long:  "Update elc binary.\nDownload release of ELC for current platform, verify its checksum and replace running binary.\nCopy of replaced binary is kept and can be restored with --rollback.",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return actions.UpdateBinaryAction(version)
+			return actions.UpdateBinaryAction(version, targetPath, rollback)
 		},
 	}
-	command.Flags().StringVar(&version, "version", "", "desired version of elc")
+	command.Flags().StringVar(&version, "version", "", "desired version of elc, eg. v1.2.3")
+	command.Flags().StringVar(&targetPath, "path", "", "path of binary to replace instead of running binary")
+	command.Flags().BoolVar(&rollback, "rollback", false, "restore binary replaced by last update")
 	parentCommand.AddCommand(command)
 }
 
 func NewFixUpdateCommand(parentCommand *cobra.Command) {
 	var command = &cobra.Command{
 		Use:   "fix-update-command",
-		Short: "Set actual update command to ~/.elc.yaml",
+		Short: "Reset update settings in ~/.elc.yaml to defaults",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return actions.FixUpdateBinaryCommandAction()
@@ -352,12 +639,26 @@ func NewFixUpdateCommand(parentCommand *cobra.Command) {
 	parentCommand.AddCommand(command)
 }
 
+func NewDoctorCommand(parentCommand *cobra.Command) {
+	var command = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check environment of elc",
+		Long:  "Check container runtime, compose, git, ~/.elc.yaml, current workspace and variables required by commands,\nprint fixes for found problems.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.DoctorAction(&globalOptions)
+		},
+	}
+	command.Annotations = map[string]string{noDefaultFlagsAnnotation: "true"}
+	parentCommand.AddCommand(command)
+}
+
 func NewServiceCloneCommand(parentCommand *cobra.Command) {
 	var noHook bool
 	var command = &cobra.Command{
-		Use:           "clone [NAME]",
+		Use:           "clone [SELECTOR...]",
 		Short:         "Clone component to its path",
-		Long:          "Clone component to its path.",
+		Long:          "Clone component to its path.\n" + selectorHelp,
 		SilenceUsage:  false,
 		SilenceErrors: false,
 		Args:          cobra.ArbitraryArgs,
@@ -367,18 +668,38 @@ func NewServiceCloneCommand(parentCommand *cobra.Command) {
 	}
 
 	command.Flags().BoolVar(&noHook, "no-hook", false, "do not execute hook script after cloning")
+	command.Annotations = allWorkspacesSupported
+	parentCommand.AddCommand(command)
+}
+
+func NewServicePullCommand(parentCommand *cobra.Command) {
+	var noHook bool
+	var command = &cobra.Command{
+		Use:   "pull [SELECTOR...]",
+		Short: "Pull changes of component repository",
+		Long:  "Pull changes of component repository and execute after_pull hook.\n" + selectorHelp,
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return actions.PullComponentAction(&globalOptions, args, noHook)
+		},
+	}
+
+	command.Flags().BoolVar(&noHook, "no-hook", false, "do not execute after_pull hook")
+	command.Annotations = allWorkspacesSupported
 	parentCommand.AddCommand(command)
 }
 
 func NewServiceListCommand(parentCommand *cobra.Command) {
+	var quiet bool
 	var command = &cobra.Command{
 		Use:   "list [OPTIONS]",
 		Short: "Show list of services",
-		Long:  "Show list of services.\nCan be used in scripts for loops.",
+		Long:  "Show list of services sorted by name, with their type, template, host component, tags and state.\nComponents can be selected with --tag, --not-tag, --type, --extends, --hosted-in, --cloned and --running.\nUse --quiet to print only names, eg. in scripts for loops.",
 		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return actions.ListServicesAction(&globalOptions)
+			return actions.ListServicesAction(&globalOptions, quiet)
 		},
 	}
+	command.Flags().BoolVarP(&quiet, "quiet", "q", false, "print only names of components")
 	parentCommand.AddCommand(command)
 }
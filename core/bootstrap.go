@@ -1,15 +1,31 @@
 package core
 
 import (
+	"errors"
+	"fmt"
 	"path"
+	"strings"
 )
 
-func CheckAndLoadHC() (*HomeConfig, error) {
+const workspaceConfigName = "workspace.yaml"
+
+// marker file in project folder, contains path to folder with workspace.yaml, absolute or relative to the marker
+const workspaceMarkerName = ".elc"
+
+func homeConfigPath() (string, error) {
 	homeDir, err := Pc.HomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(homeDir, ".elc.yaml"), nil
+}
+
+func CheckAndLoadHC() (*HomeConfig, error) {
+	homeConfigPath, err := homeConfigPath()
 	if err != nil {
 		return nil, err
 	}
-	homeConfigPath := path.Join(homeDir, ".elc.yaml")
 	err = CheckHomeConfigIsEmpty(homeConfigPath)
 	if err != nil {
 		return nil, err
@@ -22,29 +38,163 @@ func CheckAndLoadHC() (*HomeConfig, error) {
 	return hc, nil
 }
 
-func GetWorkspaceConfig(wsName string) (*Workspace, error) {
+// loadHCIfExists loads home config without creating it, so found workspace can be used on hosts where elc was never set up.
+func loadHCIfExists() (*HomeConfig, error) {
+	homeConfigPath, err := homeConfigPath()
+	if err != nil {
+		return &HomeConfig{}, nil
+	}
+	if !Pc.FileExists(homeConfigPath) {
+		return &HomeConfig{Path: homeConfigPath}, nil
+	}
+
+	return LoadHomeConfig(homeConfigPath)
+}
+
+// FindLocalWorkspace walks up from dir looking for workspace.yaml or .elc marker and returns path of workspace folder.
+// Empty string is returned if nothing is found.
+func FindLocalWorkspace(dir string) (string, error) {
+	for {
+		if Pc.FileExists(path.Join(dir, workspaceConfigName)) {
+			return dir, nil
+		}
+
+		markerPath := path.Join(dir, workspaceMarkerName)
+		if Pc.FileExists(markerPath) && !Pc.IsDir(markerPath) {
+			return readWorkspaceMarker(markerPath)
+		}
+
+		parent := path.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func readWorkspaceMarker(markerPath string) (string, error) {
+	data, err := Pc.ReadFile(markerPath)
+	if err != nil {
+		return "", err
+	}
+
+	wsPath := strings.TrimSpace(string(data))
+	if wsPath == "" {
+		return "", errors.New(fmt.Sprintf("marker %s is empty, it must contain path to workspace", markerPath))
+	}
+	if !path.IsAbs(wsPath) {
+		wsPath = path.Join(path.Dir(markerPath), wsPath)
+	}
+	if path.Base(wsPath) == workspaceConfigName {
+		wsPath = path.Dir(wsPath)
+	}
+
+	if !Pc.FileExists(path.Join(wsPath, workspaceConfigName)) {
+		return "", errors.New(fmt.Sprintf("marker %s points to %s, but there is no %s", markerPath, wsPath, workspaceConfigName))
+	}
+
+	return wsPath, nil
+}
+
+// ResolveWorkspace returns workspace for current command.
+// Workspace passed with --workspace has the highest priority, then workspace found in cwd or its parents,
+// then current_workspace of home config. Found workspace may be not registered, then only its path is set.
+func ResolveWorkspace(wsName string, cwd string) (*HomeConfig, *HomeConfigItem, error) {
+	if wsName == "" {
+		wsPath, err := FindLocalWorkspace(cwd)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if wsPath != "" {
+			hc, err := loadHCIfExists()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for index, hci := range hc.Workspaces {
+				if path.Clean(hci.Path) == wsPath {
+					return hc, &hc.Workspaces[index], nil
+				}
+			}
+
+			return hc, &HomeConfigItem{Path: wsPath}, nil
+		}
+	}
+
 	hc, err := CheckAndLoadHC()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hci, err := hc.GetCurrentWorkspace(wsName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hc, hci, nil
+}
+
+func GetWorkspaceConfig(wsName string) (*Workspace, error) {
+	cwd, err := Pc.Getwd()
 	if err != nil {
 		return nil, err
 	}
 
-	wsPath, err := hc.GetCurrentWsPath(wsName)
+	hc, hci, err := ResolveWorkspace(wsName, cwd)
 	if err != nil {
 		return nil, err
 	}
 
+	return loadWorkspace(hc, hci, cwd, true)
+}
+
+// RegisteredWorkspace is a workspace of ~/.elc.yaml loaded by command working with all workspaces at once.
+// Error is set when workspace can not be loaded, it does not prevent loading of other workspaces.
+type RegisteredWorkspace struct {
+	Name      string
+	Path      string
+	Workspace *Workspace
+	Error     error
+}
+
+// LoadRegisteredWorkspaces loads all workspaces of home config in order of registration.
+// Version of elc is never switched here, workspace pinned to another version gets an error.
+func LoadRegisteredWorkspaces() ([]RegisteredWorkspace, error) {
 	cwd, err := Pc.Getwd()
 	if err != nil {
 		return nil, err
 	}
-	ws := NewWorkspace(wsPath, cwd)
+
+	hc, err := CheckAndLoadHC()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RegisteredWorkspace, 0)
+	for index := range hc.Workspaces {
+		hci := &hc.Workspaces[index]
+		ws, err := loadWorkspace(hc, hci, cwd, false)
+		result = append(result, RegisteredWorkspace{Name: hci.Name, Path: hci.Path, Workspace: ws, Error: err})
+	}
+
+	return result, nil
+}
+
+func loadWorkspace(hc *HomeConfig, hci *HomeConfigItem, cwd string, canSwitchVersion bool) (*Workspace, error) {
+	ws := NewWorkspace(hci.Path, cwd)
+	ws.Settings = hc.Settings(hci)
+	err := CheckComposeRuntime(ws.Settings.ComposeRuntime)
+	if err != nil {
+		return nil, err
+	}
 
 	err = ws.LoadConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	err = ws.checkVersion()
+	err = ws.checkVersion(hc, canSwitchVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +206,27 @@ func GetWorkspaceConfig(wsName string) (*Workspace, error) {
 
 	return ws, nil
 }
+
+// root command 'elc <cmd>' is a short form of 'elc exec <cmd>', so it uses defaults of exec
+const rootCommandDefaults = "exec"
+
+// DefaultFlagsCommand returns name of command in defaults of ~/.elc.yaml by path of cobra command,
+// eg. "elc workspace list" -> "workspace list", "elc" -> "exec".
+func DefaultFlagsCommand(commandPath string) string {
+	parts := strings.SplitN(commandPath, " ", 2)
+	if len(parts) < 2 {
+		return rootCommandDefaults
+	}
+
+	return parts[1]
+}
+
+// DefaultFlags returns default values of flags for command from ~/.elc.yaml, command is a path like "start" or "workspace list".
+func DefaultFlags(command string) (map[string]string, error) {
+	hc, err := loadHCIfExists()
+	if err != nil {
+		return nil, err
+	}
+
+	return hc.Defaults.Flags[command], nil
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	CollisionProject = "project"
+	CollisionPort    = "port"
+	CollisionNetwork = "network"
+)
+
+// WorkspaceCollision is a host resource claimed by components of different workspaces,
+// such workspaces can not be started side by side.
+type WorkspaceCollision struct {
+	Kind       string   `json:"kind" yaml:"kind"`
+	Value      string   `json:"value" yaml:"value"`
+	Components []string `json:"components" yaml:"components"`
+}
+
+type resourceClaim struct {
+	kind      string
+	value     string
+	component string
+}
+
+// composeResources is a part of compose file describing resources of host.
+type composeResources struct {
+	Services map[string]struct {
+		Ports []interface{} `yaml:"ports"`
+	} `yaml:"services"`
+	Networks map[string]struct {
+		Name     string      `yaml:"name"`
+		External interface{} `yaml:"external"`
+	} `yaml:"networks"`
+}
+
+// FindWorkspaceCollisions returns compose projects, published ports and named networks used by more than one workspace.
+// Workspaces which were not loaded are skipped.
+func FindWorkspaceCollisions(workspaces []RegisteredWorkspace) ([]WorkspaceCollision, error) {
+	owners := make(map[string]map[string][]string)
+	for _, rw := range workspaces {
+		if rw.Workspace == nil {
+			continue
+		}
+
+		claims, err := rw.Workspace.resourceClaims()
+		if err != nil {
+			return nil, err
+		}
+		for _, claim := range claims {
+			key := claim.kind + "\x00" + claim.value
+			if owners[key] == nil {
+				owners[key] = make(map[string][]string)
+			}
+			owners[key][rw.Name] = append(owners[key][rw.Name], rw.Name+"/"+claim.component)
+		}
+	}
+
+	result := make([]WorkspaceCollision, 0)
+	for key, byWorkspace := range owners {
+		if len(byWorkspace) < 2 {
+			continue
+		}
+
+		components := make([]string, 0)
+		for _, names := range byWorkspace {
+			components = append(components, names...)
+		}
+		sort.Strings(components)
+
+		parts := strings.SplitN(key, "\x00", 2)
+		result = append(result, WorkspaceCollision{Kind: parts[0], Value: parts[1], Components: components})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	return result, nil
+}
+
+// resourceClaims returns resources of host used by services of workspace. Modules are skipped, they use containers of their hosts.
+func (ws *Workspace) resourceClaims() ([]resourceClaim, error) {
+	result := make([]resourceClaim, 0)
+	for _, name := range ws.allComponentNames() {
+		comp := ws.Components[name]
+		if comp.Config.Type() != ComponentTypeService {
+			continue
+		}
+
+		projectName, _ := comp.Context.find("COMPOSE_PROJECT_NAME")
+		if projectName != "" {
+			result = append(result, resourceClaim{CollisionProject, projectName, name})
+		}
+
+		resources, err := comp.composeResources()
+		if err != nil {
+			return nil, err
+		}
+		if resources == nil {
+			continue
+		}
+
+		for _, svc := range resources.Services {
+			for _, port := range svc.Ports {
+				if hostPort := comp.publishedPort(port); hostPort != "" {
+					result = append(result, resourceClaim{CollisionPort, hostPort, name})
+				}
+			}
+		}
+
+		for _, network := range resources.Networks {
+			if network.Name == "" || isExternalNetwork(network.External) {
+				continue
+			}
+			networkName, err := comp.Context.RenderString(network.Name)
+			if err == nil {
+				result = append(result, resourceClaim{CollisionNetwork, networkName, name})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// composeResources reads compose file of component, nil is returned if the file does not exist yet.
+func (comp *Component) composeResources() (*composeResources, error) {
+	composeFile, _ := comp.Context.find("COMPOSE_FILE")
+	if composeFile == "" || !Pc.FileExists(composeFile) {
+		return nil, nil
+	}
+
+	data, err := Pc.ReadFile(composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := &composeResources{}
+	err = yaml.Unmarshal(data, resources)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("bad compose file %s of component '%s': %s", composeFile, comp.Name, err))
+	}
+
+	return resources, nil
+}
+
+// publishedPort returns port of host from short ("8080:80", "127.0.0.1:8080:80/tcp") or long syntax of compose ports.
+// Empty string is returned for ports without published part and for values with variables which can not be resolved.
+func (comp *Component) publishedPort(port interface{}) string {
+	var value string
+	switch typed := port.(type) {
+	case string:
+		rendered, err := comp.Context.RenderString(typed)
+		if err != nil {
+			return ""
+		}
+		parts := strings.Split(strings.SplitN(rendered, "/", 2)[0], ":")
+		if len(parts) < 2 {
+			return ""
+		}
+		value = parts[len(parts)-2]
+	case map[interface{}]interface{}:
+		published, found := typed["published"]
+		if !found {
+			return ""
+		}
+		rendered, err := comp.Context.RenderString(fmt.Sprintf("%v", published))
+		if err != nil {
+			return ""
+		}
+		value = rendered
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// isExternalNetwork checks value of "external" key, external networks are shared on purpose.
+func isExternalNetwork(value interface{}) bool {
+	switch typed := value.(type) {
+	case bool:
+		return typed
+	case map[interface{}]interface{}:
+		// deprecated syntax 'external: {name: ...}'
+		return true
+	}
+
+	return false
+}
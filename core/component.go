@@ -25,16 +25,11 @@ func NewComponent(compName string, compCfg *ComponentConfig, ws *Workspace) *Com
 }
 
 func (comp *Component) init() error {
-	ctx := make(Context, len(*comp.Workspace.Context))
-	copy(ctx, *comp.Workspace.Context)
+	resolver := newVarResolver(comp.Workspace.lookup)
 
-	ctx = ctx.add("APP_NAME", comp.Name)
-	ctx = ctx.add("COMPOSE_PROJECT_NAME", fmt.Sprintf("%s-%s", comp.Workspace.Config.Name, comp.Name))
-	svcPath, err := ctx.RenderString(comp.Config.Path)
-	if err != nil {
-		return err
-	}
-	ctx = ctx.add("SVC_PATH", svcPath)
+	resolver.addLiteral("APP_NAME", comp.Name)
+	resolver.addLiteral("COMPOSE_PROJECT_NAME", fmt.Sprintf("%s-%s", comp.Workspace.Config.Name, comp.Name))
+	resolver.add("SVC_PATH", comp.Config.Path)
 
 	if comp.Config.Extends != "" {
 		tpl, found := comp.Workspace.Config.Components[comp.Config.Extends]
@@ -43,67 +38,112 @@ func (comp *Component) init() error {
 		}
 		comp.Template = &tpl
 
-		tplPath, err := ctx.RenderString(tpl.Path)
-		if err != nil {
-			return err
-		}
-		ctx = ctx.add("TPL_PATH", tplPath)
+		resolver.add("TPL_PATH", tpl.Path)
 		if tpl.ComposeFile == "" {
 			tpl.ComposeFile = "${TPL_PATH}/docker-compose.yml"
 		}
-		composeFile, err := ctx.RenderString(tpl.ComposeFile)
-		if err != nil {
-			return err
-		}
-		ctx = ctx.add("COMPOSE_FILE", composeFile)
-		for _, pair := range tpl.Variables {
-			value, err := ctx.RenderString(pair.Value.(string))
+		resolver.add("COMPOSE_FILE", tpl.ComposeFile)
+
+		if len(tpl.EnvFiles) > 0 {
+			tplPath, err := resolver.RenderString("${TPL_PATH}")
+			if err != nil {
+				return err
+			}
+			envValues, err := loadEnvFiles(tpl.EnvFiles, tplPath, resolver.RenderString)
 			if err != nil {
 				return err
 			}
-			ctx = ctx.add(pair.Key.(string), value)
+			resolver.addContext(envValues)
+		}
+		resolver.addContext(passthroughEnv(tpl.EnvPassthrough))
+
+		for _, pair := range tpl.Variables {
+			resolver.add(pair.Key.(string), pair.Value.(string))
 		}
 	}
 
 	if comp.Config.ComposeFile != "" {
-		composeFile, err := ctx.RenderString(comp.Config.ComposeFile)
+		resolver.add("COMPOSE_FILE", comp.Config.ComposeFile)
+	} else if comp.Template == nil {
+		resolver.add("COMPOSE_FILE", "${SVC_PATH}/docker-compose.yml")
+	}
+
+	// variables of template are computed in context of components which extend it
+	if comp.Config.IsTemplate {
+		return comp.setContext(resolver)
+	}
+
+	resolver.addContext(passthroughEnv(comp.Config.EnvPassthrough))
+
+	if len(comp.Config.EnvFiles) > 0 {
+		svcPath, err := resolver.RenderString("${SVC_PATH}")
 		if err != nil {
 			return err
 		}
-		ctx = ctx.add("COMPOSE_FILE", composeFile)
-	}
-	composeFile, found := ctx.find("COMPOSE_FILE")
-	if !found || composeFile == "" {
-		composeFile, err := ctx.RenderString("${SVC_PATH}/docker-compose.yml")
+		envValues, err := loadEnvFiles(comp.Config.EnvFiles, svcPath, resolver.RenderString)
 		if err != nil {
 			return err
 		}
-		ctx = ctx.add("COMPOSE_FILE", composeFile)
+		resolver.addContext(envValues)
 	}
 
 	for _, pair := range comp.Config.Variables {
-		value, err := ctx.RenderString(pair.Value.(string))
-		if err != nil {
-			return err
-		}
-		ctx = ctx.add(pair.Key.(string), value)
+		resolver.add(pair.Key.(string), pair.Value.(string))
+	}
+
+	return comp.setContext(resolver)
+}
+
+func (comp *Component) setContext(resolver *varResolver) error {
+	ctx, err := resolver.context()
+	if err != nil {
+		return err
 	}
 
-	comp.Context = &ctx
+	wsCtx := make(Context, len(*comp.Workspace.Context))
+	copy(wsCtx, *comp.Workspace.Context)
+	for _, pair := range ctx {
+		wsCtx = wsCtx.add(pair[0], pair[1])
+	}
+	comp.Context = &wsCtx
 
 	return nil
 }
 
+func (comp *Component) printCommand(command []string) {
+	_, _ = Pc.Printf(">> %s\n", comp.Workspace.Secrets.Mask(strings.Join(command, " ")))
+}
+
+// pc returns PC for processes of component, with --clean-env they get only variables of component.
+// Internal processes of elc, eg. queries of container runtime or sources of secrets, always use Pc with environment of host.
+func (comp *Component) pc(options *GlobalOptions) PC {
+	if options.CleanEnv {
+		return Pc.WithCleanEnv()
+	}
+
+	return Pc
+}
+
+// exec runs command with stdin of elc, or with prepared input, eg. refs passed by git to pre-push hook.
+func (comp *Component) exec(command []string, options *GlobalOptions) (int, error) {
+	if options.Input != nil {
+		return comp.pc(options).ExecWithInput(command, comp.Context.renderMapToEnv(), options.Input)
+	}
+
+	return comp.pc(options).ExecInteractive(command, comp.Context.renderMapToEnv())
+}
+
 func (comp *Component) execComposeToString(composeCommand []string, options *GlobalOptions) (string, error) {
 	composeFile, _ := comp.Context.find("COMPOSE_FILE")
-	command := append([]string{"docker", "compose", "-f", composeFile}, composeCommand...)
+	command := append(comp.Workspace.composeCommand(), "-f", composeFile)
+	command = append(command, composeCommand...)
 
 	if options.Debug {
-		_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+		comp.printCommand(command)
 	}
 
 	if !options.DryRun {
-		_, out, err := Pc.ExecToString(command, comp.Context.renderMapToEnv())
+		_, out, err := comp.pc(options).ExecToString(command, comp.Context.renderMapToEnv())
 		if err != nil {
 			return "", err
 		}
@@ -115,14 +155,15 @@ func (comp *Component) execComposeToString(composeCommand []string, options *Glo
 
 func (comp *Component) execComposeInteractive(composeCommand []string, options *GlobalOptions) (int, error) {
 	composeFile, _ := comp.Context.find("COMPOSE_FILE")
-	command := append([]string{"docker", "compose", "-f", composeFile}, composeCommand...)
+	command := append(comp.Workspace.composeCommand(), "-f", composeFile)
+	command = append(command, composeCommand...)
 
 	if options.Debug {
-		_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+		comp.printCommand(command)
 	}
 
 	if !options.DryRun {
-		code, err := Pc.ExecInteractive(command, comp.Context.renderMapToEnv())
+		code, err := comp.exec(command, options)
 		if err != nil {
 			return 0, err
 		}
@@ -135,11 +176,11 @@ func (comp *Component) execComposeInteractive(composeCommand []string, options *
 
 func (comp *Component) execInteractive(command []string, options *GlobalOptions) (int, error) {
 	if options.Debug {
-		_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+		comp.printCommand(command)
 	}
 
 	if !options.DryRun {
-		code, err := Pc.ExecInteractive(command, comp.Context.renderMapToEnv())
+		code, err := comp.exec(command, options)
 		if err != nil {
 			return 0, err
 		}
@@ -195,17 +236,32 @@ func (comp *Component) Start(options *GlobalOptions) error {
 	}
 
 	if !running {
+		err = comp.Workspace.EnsureNetworks(options)
+		if err != nil {
+			return err
+		}
+
+		err = comp.runLifecycleHook(options, "before_start")
+		if err != nil {
+			return err
+		}
+
 		_, err = comp.execComposeInteractive([]string{"up", "-d"}, options)
 		if err != nil {
 			return err
 		}
+
+		err = comp.runLifecycleHook(options, "after_start")
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (comp *Component) startDependencies(params *GlobalOptions) error {
-	for _, depName := range comp.Config.GetDeps(params.Mode) {
+	for _, depName := range comp.Config.GetDeps(comp.Workspace.dependencyMode(params.Mode)) {
 		depComp, found := comp.Workspace.Components[depName]
 		if !found {
 			return errors.New(fmt.Sprintf("dependency with name '%s' is not defined", depName))
@@ -235,10 +291,20 @@ func (comp *Component) Stop(options *GlobalOptions) error {
 		return err
 	}
 	if running {
+		err = comp.runLifecycleHook(options, "before_stop")
+		if err != nil {
+			return err
+		}
+
 		_, err = comp.execComposeInteractive([]string{"stop"}, options)
 		if err != nil {
 			return err
 		}
+
+		err = comp.runLifecycleHook(options, "after_stop")
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -260,7 +326,17 @@ func (comp *Component) Destroy(options *GlobalOptions) error {
 		return err
 	}
 	if running {
-		_, err := comp.execComposeInteractive([]string{"down"}, options)
+		err = comp.runLifecycleHook(options, "before_stop")
+		if err != nil {
+			return err
+		}
+
+		_, err = comp.execComposeInteractive([]string{"down"}, options)
+		if err != nil {
+			return err
+		}
+
+		err = comp.runLifecycleHook(options, "after_stop")
 		if err != nil {
 			return err
 		}
@@ -283,7 +359,7 @@ func (comp *Component) Restart(hard bool, options *GlobalOptions) error {
 			return err
 		}
 	}
-	err = comp.Start(&GlobalOptions{})
+	err = comp.Start(options)
 	if err != nil {
 		return err
 	}
@@ -311,11 +387,18 @@ func (comp *Component) Compose(params *GlobalOptions) (int, error) {
 }
 
 func (comp *Component) Exec(options *GlobalOptions) (int, error) {
-	err := comp.Start(options)
+	startOptions := *options
+	startOptions.Input = nil
+	err := comp.Start(&startOptions)
 	if err != nil {
 		return 0, err
 	}
 
+	return comp.execInContainer(options)
+}
+
+// execInContainer executes options.Cmd in container 'app' of service, service must be already running.
+func (comp *Component) execInContainer(options *GlobalOptions) (int, error) {
 	command := []string{"exec"}
 	if options.WorkingDir != "" {
 		command = append(command, "-w", options.WorkingDir)
@@ -330,7 +413,7 @@ func (comp *Component) Exec(options *GlobalOptions) (int, error) {
 
 		groupId, found := comp.Context.find("GROUP_ID")
 		if !found {
-			return 0, errors.New("variable USER_ID is not defined")
+			return 0, errors.New("variable GROUP_ID is not defined")
 		}
 
 		command = append(command, "-u", fmt.Sprintf("%s:%s", userId, groupId))
@@ -361,6 +444,11 @@ func (comp *Component) Run(options *GlobalOptions) (int, error) {
 		return 1, nil
 	}
 
+	err = comp.Workspace.EnsureNetworks(options)
+	if err != nil {
+		return 1, err
+	}
+
 	command := []string{"run", "--rm", "--entrypoint=''"}
 	if options.WorkingDir != "" {
 		command = append(command, "-w", options.WorkingDir)
@@ -375,7 +463,7 @@ func (comp *Component) Run(options *GlobalOptions) (int, error) {
 
 		groupId, found := comp.Context.find("GROUP_ID")
 		if !found {
-			return 0, errors.New("variable USER_ID is not defined")
+			return 0, errors.New("variable GROUP_ID is not defined")
 		}
 
 		command = append(command, "-u", fmt.Sprintf("%s:%s", userId, groupId))
@@ -406,6 +494,15 @@ func (comp *Component) Wrap(command []string, options *GlobalOptions) (int, erro
 
 func (comp *Component) DumpVars() error {
 	for _, line := range comp.Context.renderMapToEnv() {
+		_, _ = Pc.Println(comp.Workspace.Secrets.Mask(line))
+	}
+
+	return nil
+}
+
+// ExportDotenv prints variables in .env format with real secret values, so the output can be redirected to a file for IDE.
+func (comp *Component) ExportDotenv() error {
+	for _, line := range comp.Context.renderMapToDotenv() {
 		_, _ = Pc.Println(line)
 	}
 
@@ -424,6 +521,19 @@ func (comp *Component) getAfterCloneHook() string {
 	return ""
 }
 
+// getHookCommands returns commands of hook from component config, or from template if component does not define the hook.
+func (comp *Component) getHookCommands(hookName string) []string {
+	if commands, found := comp.Config.Hooks[hookName]; found {
+		return commands
+	}
+
+	if comp.Template != nil {
+		return comp.Template.Hooks[hookName]
+	}
+
+	return nil
+}
+
 func (comp *Component) Clone(options *GlobalOptions, noHook bool) error {
 	cloned, err := comp.IsCloned()
 	if err != nil {
@@ -446,31 +556,46 @@ func (comp *Component) Clone(options *GlobalOptions, noHook bool) error {
 		_, _ = Pc.Printf("Folder of component %s already exists. Skip.\n", comp.Name)
 		return nil
 	} else {
-		_, err := comp.execInteractive([]string{"git", "clone", comp.Config.Repository, svcPath}, options)
+		code, err := comp.execInteractive([]string{"git", "clone", comp.Config.Repository, svcPath}, options)
 		if err != nil {
 			return err
 		}
+		if code != 0 {
+			return errors.New(fmt.Sprintf("git clone failed with exit code %d", code))
+		}
 
 		if !noHook {
-			afterCloneHook := comp.getAfterCloneHook()
-			if afterCloneHook == "" {
-				return nil
-			}
+			return comp.runLifecycleHook(options, "after_clone")
+		}
+		return nil
+	}
+}
 
-			afterCloneHook, err = comp.Context.RenderString(afterCloneHook)
-			if err != nil {
-				return err
-			}
+func (comp *Component) Pull(options *GlobalOptions, noHook bool) error {
+	cloned, err := comp.IsCloned()
+	if err != nil {
+		return err
+	}
 
-			if afterCloneHook != "" {
-				_, err = comp.execInteractive([]string{afterCloneHook}, options)
-				if err != nil {
-					return err
-				}
-			}
-		}
+	if !cloned {
+		_, _ = Pc.Println("component is not cloned")
 		return nil
 	}
+
+	svcPath, _ := comp.Context.find("SVC_PATH")
+	code, err := comp.execInteractive([]string{"git", "-C", svcPath, "pull"}, options)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return errors.New(fmt.Sprintf("git pull failed with exit code %d", code))
+	}
+
+	if !noHook {
+		return comp.runLifecycleHook(options, "after_pull")
+	}
+
+	return nil
 }
 
 func (comp *Component) UpdateHooks(options *GlobalOptions, elcBinary string, scriptsFolder string) error {
@@ -479,5 +604,23 @@ func (comp *Component) UpdateHooks(options *GlobalOptions, elcBinary string, scr
 		return errors.New("path of component is not defined.Check workspace.yaml")
 	}
 
-	return GenerateHookScripts(options, svcPath, elcBinary, scriptsFolder)
+	return GenerateHookScripts(options, svcPath, elcBinary, scriptsFolder, configuredHookNames(comp.getHookCommands))
+}
+
+func (comp *Component) RemoveHooks(options *GlobalOptions) error {
+	svcPath, found := comp.Context.find("SVC_PATH")
+	if !found {
+		return errors.New("path of component is not defined.Check workspace.yaml")
+	}
+
+	return RemoveHookScripts(options, svcPath)
+}
+
+func (comp *Component) CheckHooks(elcBinary string, scriptsFolder string) ([]string, error) {
+	svcPath, found := comp.Context.find("SVC_PATH")
+	if !found {
+		return nil, errors.New("path of component is not defined.Check workspace.yaml")
+	}
+
+	return CheckHookScripts(svcPath, elcBinary, scriptsFolder, configuredHookNames(comp.getHookCommands))
 }
@@ -1,6 +1,10 @@
 package core
 
-import "gopkg.in/yaml.v2"
+import (
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
 
 type ModeList []string
 
@@ -25,9 +29,12 @@ type ComponentConfig struct {
 	Path           string              `yaml:"path"`
 	Replace        bool                `yaml:"replace"`
 	Variables      yaml.MapSlice       `yaml:"variables"`
+	EnvFiles       []string            `yaml:"env_files"`
+	EnvPassthrough []string            `yaml:"env_passthrough"`
 	Repository     string              `yaml:"repository"`
 	Tags           []string            `yaml:"tags"`
 	AfterCloneHook string              `yaml:"after_clone_hook"`
+	Hooks          map[string][]string `yaml:"hooks"`
 }
 
 func (cc ComponentConfig) merge(cc2 ComponentConfig) ComponentConfig {
@@ -61,8 +68,21 @@ func (cc ComponentConfig) merge(cc2 ComponentConfig) ComponentConfig {
 	}
 
 	cc.Variables = append(cc.Variables, cc2.Variables...)
+	cc.EnvFiles = append(cc.EnvFiles, cc2.EnvFiles...)
+	cc.EnvPassthrough = append(cc.EnvPassthrough, cc2.EnvPassthrough...)
 	cc.Tags = append(cc.Tags, cc2.Tags...)
 
+	if len(cc2.Hooks) > 0 {
+		hooks := make(map[string][]string)
+		for hookName, commands := range cc.Hooks {
+			hooks[hookName] = commands
+		}
+		for hookName, commands := range cc2.Hooks {
+			hooks[hookName] = commands
+		}
+		cc.Hooks = hooks
+	}
+
 	for depSvc, modes := range cc2.Dependencies {
 		if cc.Dependencies[depSvc] == nil {
 			cc.Dependencies[depSvc] = make([]string, 1)
@@ -84,6 +104,7 @@ func (cc *ComponentConfig) GetDeps(mode string) []string {
 			result = append(result, key)
 		}
 	}
+	sort.Strings(result)
 
 	return result
 }
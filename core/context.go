@@ -33,8 +33,13 @@ func (ctx *Context) add(name string, value string) Context {
 	return append(tmp, []string{name, value})
 }
 
+func (ctx *Context) lookup(name string) (string, bool, error) {
+	value, found := ctx.find(name)
+	return value, found, nil
+}
+
 func (ctx *Context) RenderString(str string) (string, error) {
-	return substVars(str, ctx)
+	return substVars(str, ctx.lookup)
 }
 
 func (ctx *Context) renderMapToEnv() []string {
@@ -45,3 +50,12 @@ func (ctx *Context) renderMapToEnv() []string {
 
 	return result
 }
+
+func (ctx *Context) renderMapToDotenv() []string {
+	var result []string
+	for _, pair := range *ctx {
+		result = append(result, fmt.Sprintf("%s=%s", pair[0], formatDotenvValue(pair[1])))
+	}
+
+	return result
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	CheckOk      = "ok"
+	CheckWarning = "warning"
+	CheckError   = "error"
+)
+
+// default socket of docker daemon, it is checked only when DOCKER_HOST is not set
+const dockerSocketPath = "/var/run/docker.sock"
+
+// DoctorCheck is a result of one check of 'elc doctor' for machine-readable output, field names must not be changed.
+type DoctorCheck struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Fix     string `json:"fix" yaml:"fix"`
+}
+
+type doctor struct {
+	options *GlobalOptions
+	checks  []DoctorCheck
+}
+
+func (d *doctor) add(name string, status string, message string, fix string) {
+	d.checks = append(d.checks, DoctorCheck{Name: name, Status: status, Message: message, Fix: fix})
+}
+
+// Diagnose checks environment of elc: home config, current workspace, container runtime, compose, git and variables
+// required by commands. Checks never change anything, failed check does not stop others, checks depending on it are skipped.
+func Diagnose(options *GlobalOptions) []DoctorCheck {
+	d := &doctor{options: options, checks: make([]DoctorCheck, 0)}
+
+	hc, hcExists := d.checkHomeConfig()
+	ws := d.checkWorkspace(hc, hcExists)
+
+	settings := HomeSettings{}
+	if ws != nil {
+		settings = ws.Settings
+	} else if hc != nil {
+		settings = hc.Defaults.HomeSettings
+	}
+	runtimeWs := &Workspace{Settings: settings}
+
+	runtimeOk := d.checkContainerEngine(runtimeWs)
+	d.checkCompose(runtimeWs)
+	d.checkGit()
+
+	if ws != nil {
+		d.checkUserVariables(ws)
+		if runtimeOk {
+			d.checkNetworks(ws)
+		}
+	}
+
+	return d.checks
+}
+
+func (d *doctor) checkHomeConfig() (*HomeConfig, bool) {
+	configPath, err := homeConfigPath()
+	if err != nil {
+		d.add("home config", CheckError, err.Error(), "check that home directory of your user exists")
+		return nil, false
+	}
+
+	if !Pc.FileExists(configPath) {
+		d.add("home config", CheckWarning, fmt.Sprintf("%s does not exist", configPath), "register workspace with 'elc workspace add NAME PATH'")
+		return &HomeConfig{Path: configPath}, false
+	}
+
+	hc, err := LoadHomeConfig(configPath)
+	if err != nil {
+		d.add("home config", CheckError, err.Error(), fmt.Sprintf("fix syntax of %s or restore it from %s.bak", configPath, configPath))
+		return nil, true
+	}
+
+	problems := hc.Problems()
+	if len(problems) > 0 {
+		d.add("home config", CheckWarning, fmt.Sprintf("found %d problems of registered workspaces in %s", len(problems), configPath), "run 'elc workspace doctor' for details")
+	} else {
+		d.add("home config", CheckOk, configPath, "")
+	}
+
+	return hc, true
+}
+
+// checkWorkspace resolves and loads workspace of current directory, home config is never created here.
+func (d *doctor) checkWorkspace(hc *HomeConfig, hcExists bool) *Workspace {
+	cwd, err := Pc.Getwd()
+	if err != nil {
+		d.add("workspace", CheckError, err.Error(), "")
+		return nil
+	}
+
+	const resolveFix = "run elc in folder of workspace, select workspace with 'elc workspace select NAME' or pass it with --workspace"
+	if !hcExists {
+		if d.options.WorkspaceName != "" {
+			d.add("workspace", CheckError, fmt.Sprintf("workspace '%s' is not registered", d.options.WorkspaceName), "register workspace with 'elc workspace add NAME PATH'")
+			return nil
+		}
+		wsPath, err := FindLocalWorkspace(cwd)
+		if err != nil {
+			d.add("workspace", CheckError, err.Error(), resolveFix)
+			return nil
+		}
+		if wsPath == "" {
+			d.add("workspace", CheckError, "workspace is not found", resolveFix)
+			return nil
+		}
+	}
+	if hc == nil {
+		d.add("workspace", CheckError, "workspace is not resolved, because home config is broken", "fix home config first")
+		return nil
+	}
+
+	hc, hci, err := ResolveWorkspace(d.options.WorkspaceName, cwd)
+	if err != nil {
+		d.add("workspace", CheckError, err.Error(), resolveFix)
+		return nil
+	}
+
+	ws := NewWorkspace(hci.Path, cwd)
+	ws.Settings = hc.Settings(hci)
+	err = CheckComposeRuntime(ws.Settings.ComposeRuntime)
+	if err == nil {
+		err = ws.LoadConfig()
+	}
+	if err != nil {
+		d.add("workspace", CheckError, err.Error(), fmt.Sprintf("check %s/workspace.yaml and compose_runtime in ~/.elc.yaml", hci.Path))
+		return nil
+	}
+
+	d.checkVersion(ws)
+
+	err = ws.init()
+	if err != nil {
+		d.add("workspace", CheckError, err.Error(), fmt.Sprintf("check variables in %s/workspace.yaml and env.yaml", hci.Path))
+		return nil
+	}
+
+	message := hci.Path
+	if hci.Name != "" {
+		message = fmt.Sprintf("%s at %s", hci.Name, hci.Path)
+	}
+	d.add("workspace", CheckOk, message, "")
+
+	return ws
+}
+
+func (d *doctor) checkVersion(ws *Workspace) {
+	if _, err := version.NewVersion(Version); err != nil {
+		d.add("elc version", CheckWarning, fmt.Sprintf("development build '%s', requirements of workspace are not checked", Version), "use released binary of elc")
+		return
+	}
+
+	if ws.Config.ElcVersion != "" {
+		_, matches, err := ws.pinnedVersionMatches()
+		switch {
+		case err != nil:
+			d.add("elc version", CheckError, err.Error(), "fix elc_version in workspace.yaml")
+		case !matches:
+			d.add("elc version", CheckWarning,
+				fmt.Sprintf("workspace requires elc version %s, running version is %s", ws.Config.ElcVersion, Version),
+				"nothing to do, matching binary is started automatically and cached in ~/.elc/versions")
+		default:
+			d.add("elc version", CheckOk, Version, "")
+		}
+		return
+	}
+
+	err := ws.checkVersion(nil, false)
+	if err != nil {
+		d.add("elc version", CheckError, err.Error(), "update elc with 'elc update'")
+		return
+	}
+
+	d.add("elc version", CheckOk, Version, "")
+}
+
+func (d *doctor) checkContainerEngine(ws *Workspace) bool {
+	engine := ws.containerEngine()
+	format := "{{.Server.Version}}"
+	if engine == ComposeRuntimePodman {
+		format = "{{.Client.Version}}"
+	}
+
+	exitCode, out, err := Pc.ExecToString([]string{engine, "version", "--format", format}, []string{})
+	if exitCode < 0 {
+		d.add("container runtime", CheckError, fmt.Sprintf("%s is not installed", engine), fmt.Sprintf("install %s or set compose_runtime in ~/.elc.yaml", engine))
+		return false
+	}
+	if err != nil || exitCode != 0 {
+		message := fmt.Sprintf("%s daemon is not available", engine)
+		fix := fmt.Sprintf("start %s daemon, eg. 'sudo systemctl start %s'", engine, engine)
+		if _, found := Pc.LookupEnv("DOCKER_HOST"); engine == ComposeRuntimeDocker && !found && Pc.FileExists(dockerSocketPath) {
+			// socket file is left by stopped daemon too, so only failed connection tells what is wrong
+			dialErr := Pc.DialUnix(dockerSocketPath)
+			if errors.Is(dialErr, os.ErrPermission) {
+				message = fmt.Sprintf("permission denied to %s", dockerSocketPath)
+				fix = "add your user to group docker with 'sudo usermod -aG docker $USER' and log in again"
+			} else if dialErr != nil {
+				message = fmt.Sprintf("%s daemon is not running, %s does not accept connections", engine, dockerSocketPath)
+			}
+		}
+		d.add("container runtime", CheckError, message, fix)
+		return false
+	}
+
+	d.add("container runtime", CheckOk, fmt.Sprintf("%s %s", engine, strings.TrimSpace(out)), "")
+	return true
+}
+
+func (d *doctor) checkCompose(ws *Workspace) {
+	command := append(ws.composeCommand(), "version", "--short")
+	name := strings.Join(ws.composeCommand(), " ")
+
+	exitCode, out, err := Pc.ExecToString(command, []string{})
+	if err != nil || exitCode != 0 {
+		d.add("compose", CheckError, fmt.Sprintf("'%s' is not available", name),
+			"install compose plugin v2 (https://docs.docker.com/compose/install/) or set compose_runtime in ~/.elc.yaml")
+		return
+	}
+
+	composeVersion := strings.TrimPrefix(strings.TrimSpace(out), "v")
+	v, err := version.NewVersion(composeVersion)
+	if err == nil && v.Segments()[0] < 2 {
+		d.add("compose", CheckWarning, fmt.Sprintf("%s %s is outdated", name, composeVersion), "install compose plugin v2 (https://docs.docker.com/compose/install/)")
+		return
+	}
+
+	d.add("compose", CheckOk, fmt.Sprintf("%s %s", name, composeVersion), "")
+}
+
+func (d *doctor) checkGit() {
+	exitCode, out, err := Pc.ExecToString([]string{"git", "--version"}, []string{})
+	if err != nil || exitCode != 0 {
+		d.add("git", CheckError, "git is not available", "install git, it is used by clone and pull")
+		return
+	}
+
+	d.add("git", CheckOk, strings.TrimSpace(out), "")
+}
+
+// checkUserVariables checks variables used by exec and run to start commands with uid of current user.
+func (d *doctor) checkUserVariables(ws *Workspace) {
+	uid := Pc.Getuid()
+	missing := make([]string, 0)
+	for _, name := range []string{"USER_ID", "GROUP_ID"} {
+		if _, found := ws.Context.find(name); !found {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		d.add("user variables", CheckError,
+			fmt.Sprintf("variables %s are not defined, they are required by exec and run", strings.Join(missing, ", ")),
+			fmt.Sprintf("add them to variables of ~/.elc.yaml or env.yaml, eg. USER_ID: %d (see 'id -u' and 'id -g')", uid))
+		return
+	}
+
+	userId, _ := ws.Context.find("USER_ID")
+	if userId != strconv.Itoa(uid) {
+		d.add("user variables", CheckWarning,
+			fmt.Sprintf("USER_ID is %s, but your uid is %d, files created in containers will belong to another user", userId, uid),
+			fmt.Sprintf("set USER_ID: %d in variables of ~/.elc.yaml or env.yaml", uid))
+		return
+	}
+
+	d.add("user variables", CheckOk, fmt.Sprintf("USER_ID=%s", userId), "")
+}
+
+// checkNetworks checks that networks from variables NETWORK and *_NETWORK exist, compose files use them as external.
+// Missing networks from section 'networks' of workspace.yaml are fine, elc creates them on start.
+func (d *doctor) checkNetworks(ws *Workspace) {
+	engine := ws.containerEngine()
+	managed := make(map[string]bool)
+	networks, err := ws.Networks()
+	if err != nil {
+		d.add("networks", CheckError, err.Error(), "fix section 'networks' of workspace.yaml")
+	}
+	for _, network := range networks {
+		managed[network.Name] = true
+	}
+
+	for _, pair := range *ws.Context {
+		name, network := pair[0], pair[1]
+		if (name != "NETWORK" && !strings.HasSuffix(name, "_NETWORK")) || network == "" {
+			continue
+		}
+
+		exitCode, _, err := Pc.ExecToString([]string{engine, "network", "inspect", "--format", "{{.Name}}", network}, []string{})
+		if (err != nil || exitCode != 0) && managed[network] {
+			d.add("network "+network, CheckOk, fmt.Sprintf("used by variable %s, will be created on start", name), "")
+			continue
+		}
+		if err != nil || exitCode != 0 {
+			d.add("network "+network, CheckError, fmt.Sprintf("network %s from variable %s does not exist", network, name),
+				fmt.Sprintf("create it with '%s network create %s'", engine, network))
+			continue
+		}
+
+		d.add("network "+network, CheckOk, fmt.Sprintf("used by variable %s", name), "")
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+func parseDotenv(data []byte) (Context, error) {
+	ctx := make(Context, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		eq := strings.Index(line, "=")
+		if eq < 1 {
+			return nil, errors.New(fmt.Sprintf("bad dotenv line %d: '%s'", lineNum, line))
+		}
+		name := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			quote := value[0]
+			value = value[1 : len(value)-1]
+			if quote == '"' {
+				value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value)
+			}
+		} else if hash := strings.Index(value, " #"); hash > -1 {
+			value = strings.TrimSpace(value[:hash])
+		}
+
+		ctx = ctx.add(name, value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+func loadEnvFiles(envFiles []string, baseDir string, render func(str string) (string, error)) (Context, error) {
+	ctx := make(Context, 0)
+	for _, envFile := range envFiles {
+		envPath, err := render(envFile)
+		if err != nil {
+			return nil, err
+		}
+		if !path.IsAbs(envPath) {
+			envPath = path.Join(baseDir, envPath)
+		}
+
+		if !Pc.FileExists(envPath) {
+			return nil, errors.New(fmt.Sprintf("env file %s is not found", envPath))
+		}
+		data, err := Pc.ReadFile(envPath)
+		if err != nil {
+			return nil, err
+		}
+		values, err := parseDotenv(data)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("env file %s: %s", envPath, err))
+		}
+
+		for _, pair := range values {
+			ctx = ctx.add(pair[0], pair[1])
+		}
+	}
+
+	return ctx, nil
+}
+
+func formatDotenvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'#$\\`") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n") {
+		return "'" + value + "'"
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
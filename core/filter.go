@@ -0,0 +1,249 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	ComponentTypeService  = "service"
+	ComponentTypeModule   = "module"
+	ComponentTypeTemplate = "template"
+)
+
+// Type returns kind of component: template, module (component hosted in container of another one) or service.
+func (cc *ComponentConfig) Type() string {
+	if cc.IsTemplate {
+		return ComponentTypeTemplate
+	}
+	if cc.HostedIn != "" {
+		return ComponentTypeModule
+	}
+
+	return ComponentTypeService
+}
+
+// ComponentFilter selects components by their config and state, it is shared by all commands working with many components.
+// Component matches if it has any of Tags and none of NotTags, other conditions are checked only when they are set.
+type ComponentFilter struct {
+	Type     string
+	Tags     []string
+	NotTags  []string
+	Extends  string
+	HostedIn string
+	Cloned   bool
+	Running  bool
+}
+
+func (f *ComponentFilter) IsEmpty() bool {
+	return f.Type == "" && len(f.Tags) == 0 && len(f.NotTags) == 0 && f.Extends == "" && f.HostedIn == "" && !f.Cloned && !f.Running
+}
+
+func (f *ComponentFilter) Validate() error {
+	if f.Type != "" && f.Type != ComponentTypeService && f.Type != ComponentTypeModule && f.Type != ComponentTypeTemplate {
+		return errors.New(fmt.Sprintf("unknown component type '%s', expected one of: service, module, template", f.Type))
+	}
+
+	return nil
+}
+
+func (f *ComponentFilter) String() string {
+	var parts []string
+	if f.Type != "" {
+		parts = append(parts, "type "+f.Type)
+	}
+	if len(f.Tags) > 0 {
+		parts = append(parts, "tag "+strings.Join(f.Tags, ", "))
+	}
+	if len(f.NotTags) > 0 {
+		parts = append(parts, "without tag "+strings.Join(f.NotTags, ", "))
+	}
+	if f.Extends != "" {
+		parts = append(parts, "extends "+f.Extends)
+	}
+	if f.HostedIn != "" {
+		parts = append(parts, "hosted in "+f.HostedIn)
+	}
+	if f.Cloned {
+		parts = append(parts, "cloned")
+	}
+	if f.Running {
+		parts = append(parts, "running")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (f *ComponentFilter) matchConfig(cc *ComponentConfig) bool {
+	if f.Type != "" {
+		if cc.Type() != f.Type {
+			return false
+		}
+	} else if cc.IsTemplate {
+		return false
+	}
+
+	if len(f.Tags) > 0 {
+		found := false
+		for _, tag := range f.Tags {
+			if contains(cc.Tags, tag) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, tag := range f.NotTags {
+		if contains(cc.Tags, tag) {
+			return false
+		}
+	}
+
+	if f.Extends != "" && cc.Extends != f.Extends {
+		return false
+	}
+	if f.HostedIn != "" && cc.HostedIn != f.HostedIn {
+		return false
+	}
+
+	return true
+}
+
+// ComponentState is a state of component on host, it is collected only when it is needed, because it requires docker calls.
+type ComponentState struct {
+	Cloned  bool
+	Running bool
+}
+
+// runningProjects returns names of compose projects with running containers, one docker call is used for all components.
+func (ws *Workspace) runningProjects(options *GlobalOptions) (map[string]bool, error) {
+	command := []string{ws.containerEngine(), "ps", "--format", "{{.Label \"com.docker.compose.project\"}}"}
+	if options.Debug {
+		_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+	}
+
+	result := make(map[string]bool)
+	if options.DryRun {
+		return result, nil
+	}
+
+	_, out, err := Pc.ExecToString(command, []string{})
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			result[line] = true
+		}
+	}
+
+	return result, nil
+}
+
+// ComponentStates returns state of every component from names.
+// State of module is a state of component which hosts it, because module has no own containers.
+func (ws *Workspace) ComponentStates(names []string, options *GlobalOptions) (map[string]ComponentState, error) {
+	projects, err := ws.runningProjects(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return ws.componentStates(names, projects)
+}
+
+// ComponentStatesIfAvailable is like ComponentStates, but unavailable container runtime is not an error,
+// all components are not running then and false is returned as second value.
+func (ws *Workspace) ComponentStatesIfAvailable(names []string, options *GlobalOptions) (map[string]ComponentState, bool, error) {
+	projects, err := ws.runningProjects(options)
+	runtimeAvailable := err == nil
+	if !runtimeAvailable {
+		projects = make(map[string]bool)
+	}
+
+	states, err := ws.componentStates(names, projects)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return states, runtimeAvailable, nil
+}
+
+func (ws *Workspace) componentStates(names []string, projects map[string]bool) (map[string]ComponentState, error) {
+	result := make(map[string]ComponentState)
+	for _, name := range names {
+		comp, err := ws.ComponentByName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		cloned, err := comp.IsCloned()
+		if err != nil {
+			return nil, err
+		}
+
+		hostComp, err := comp.hostComponent()
+		if err != nil {
+			return nil, err
+		}
+		projectName, _ := hostComp.Context.find("COMPOSE_PROJECT_NAME")
+
+		result[name] = ComponentState{Cloned: cloned, Running: projects[projectName]}
+	}
+
+	return result, nil
+}
+
+// FindComponentNames returns sorted names of components matching filter.
+func (ws *Workspace) FindComponentNames(filter *ComponentFilter, options *GlobalOptions) ([]string, error) {
+	return ws.FilterComponentNames(ws.ComponentNames, filter, options)
+}
+
+func (ws *Workspace) FindComponentNamesOrError(filter *ComponentFilter, options *GlobalOptions) ([]string, error) {
+	names, err := ws.FindComponentNames(filter, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New(fmt.Sprintf("components with %s not found", filter))
+	}
+
+	return names, nil
+}
+
+// FilterComponentNames returns names matching filter keeping their order.
+func (ws *Workspace) FilterComponentNames(names []string, filter *ComponentFilter, options *GlobalOptions) ([]string, error) {
+	result := make([]string, 0)
+	for _, name := range names {
+		comp, err := ws.ComponentByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if filter.matchConfig(comp.Config) {
+			result = append(result, name)
+		}
+	}
+
+	if !filter.Cloned && !filter.Running {
+		return result, nil
+	}
+
+	states, err := ws.ComponentStates(result, options)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]string, 0)
+	for _, name := range result {
+		state := states[name]
+		if (filter.Cloned && !state.Cloned) || (filter.Running && !state.Running) {
+			continue
+		}
+		filtered = append(filtered, name)
+	}
+
+	return filtered, nil
+}
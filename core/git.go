@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -37,25 +38,168 @@ var hookNames = []string{
 	"post-index-change",
 }
 
+const hookSignature = "# generated by elc, do not edit"
+
+// suffix of hook which was installed before elc, elc hook calls it before own scripts
+const chainedHookSuffix = ".pre-elc"
+
 var hookScript = `#!/bin/bash
+` + hookSignature + `
 set -e
 
 ELC_BINARY="%s"
 HOOKS_FOLDER="%s"
 HOOK_NAME="%s"
+READS_STDIN="%d"
+CHAINED_HOOK="$(dirname "$0")/$HOOK_NAME` + chainedHookSuffix + `"
+
+if [ "$READS_STDIN" = "1" ]; then
+    HOOK_INPUT="$(cat)"
+fi
+
+run() {
+    if [ "$READS_STDIN" = "1" ]; then
+        printf '%%s\n' "$HOOK_INPUT" | "$@"
+    else
+        "$@"
+    fi
+}
+
+if [ -x "$CHAINED_HOOK" ]; then
+    run "$CHAINED_HOOK" "$@"
+fi
 
 if command -v $ELC_BINARY &> /dev/null; then
-    $ELC_BINARY --mode=hook --no-tty $0
+    run $ELC_BINARY hook run --hooks-dir="$HOOKS_FOLDER" "$HOOK_NAME" "$@"
+elif [ -z "$HOOKS_FOLDER" ]; then
+    echo "$ELC_BINARY is not found, hooks from workspace config can not be executed" >&2
+    exit 1
 else
     for script in ./$HOOKS_FOLDER/$HOOK_NAME/* ; do
         if [ -f $script ]; then
-            $script
+            run $script "$@"
         fi
     done
 fi
 `
 
-func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary string, scriptsFolder string) error {
+func renderHookScript(elcBinary string, scriptsFolder string, hookName string) string {
+	readsStdin := 0
+	if contains(stdinHookNames, hookName) {
+		readsStdin = 1
+	}
+
+	return fmt.Sprintf(hookScript, elcBinary, scriptsFolder, hookName, readsStdin)
+}
+
+// lines of wrappers generated by previous versions of elc, which had no hookSignature
+var legacyHookMarkers = []string{"ELC_BINARY=", "HOOKS_FOLDER=", "HOOK_NAME=", "--mode=hook"}
+
+// isElcHook checks that hook is generated by elc, current or previous version, such hook is overwritten or removed.
+func isElcHook(hookPath string) (bool, error) {
+	data, err := Pc.ReadFile(hookPath)
+	if err != nil {
+		return false, err
+	}
+
+	content := string(data)
+	if strings.Contains(content, hookSignature) {
+		return true, nil
+	}
+
+	for _, marker := range legacyHookMarkers {
+		if !strings.Contains(content, marker) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func normalizeScriptsFolder(scriptsFolder string) string {
+	scriptsFolder = strings.ReplaceAll(scriptsFolder, "./", "")
+	return strings.Trim(scriptsFolder, "/")
+}
+
+// configuredHookNames returns names of git hooks which have at least one command in config.
+func configuredHookNames(getCommands func(hookName string) []string) []string {
+	var result []string
+	for _, hookName := range hookNames {
+		if len(getCommands(hookName)) > 0 {
+			result = append(result, hookName)
+		}
+	}
+
+	return result
+}
+
+// usedHookNames returns names of hooks which have at least one script in scripts folder.
+func usedHookNames(svcPath string, scriptsFolder string) ([]string, error) {
+	var result []string
+	for _, hookName := range hookNames {
+		scripts, err := findHookScripts(svcPath, scriptsFolder, hookName)
+		if err != nil {
+			return nil, err
+		}
+		if len(scripts) > 0 {
+			result = append(result, hookName)
+		}
+	}
+
+	return result, nil
+}
+
+func moveFile(options *GlobalOptions, from string, to string) error {
+	if options.Debug {
+		_, _ = Pc.Printf("mv %s %s\n", from, to)
+	}
+
+	if !options.DryRun {
+		return Pc.Rename(from, to)
+	}
+
+	return nil
+}
+
+func removeFile(options *GlobalOptions, filePath string) error {
+	if options.Debug {
+		_, _ = Pc.Printf("rm %s\n", filePath)
+	}
+
+	if !options.DryRun {
+		return Pc.Remove(filePath)
+	}
+
+	return nil
+}
+
+// removeElcHook deletes hook generated by elc and restores hook which was installed before it.
+func removeElcHook(options *GlobalOptions, scriptPath string) error {
+	if Pc.FileExists(scriptPath) {
+		isElc, err := isElcHook(scriptPath)
+		if err != nil {
+			return err
+		}
+		if !isElc {
+			return nil
+		}
+
+		err = removeFile(options, scriptPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if Pc.FileExists(scriptPath + chainedHookSuffix) {
+		return moveFile(options, scriptPath+chainedHookSuffix, scriptPath)
+	}
+
+	return nil
+}
+
+// GenerateHookScripts installs wrappers of hooks with scripts in scriptsFolder,
+// or of configuredHooks when scriptsFolder is empty.
+func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary string, scriptsFolder string, configuredHooks []string) error {
 	gitPath := fmt.Sprintf("%s/.git", svcPath)
 	if Pc.FileExists(gitPath) == false {
 		_, _ = Pc.Println(fmt.Sprintf("\033[0;33mRepository %s is not exists, skip hooks installation.\033[0m", gitPath))
@@ -76,34 +220,49 @@ func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary strin
 		}
 	}
 
-	scriptsFolder = strings.ReplaceAll(scriptsFolder, "./", "")
-	scriptsFolder = strings.Trim(scriptsFolder, "/")
+	usedHooks := configuredHooks
+	if scriptsFolder != "" {
+		scriptsFolder = normalizeScriptsFolder(scriptsFolder)
 
-	scriptsFolderPath := fmt.Sprintf("%s/%s", svcPath, scriptsFolder)
-	if Pc.FileExists(scriptsFolderPath) == false {
-		_, _ = Pc.Println(fmt.Sprintf("\033[0;33mFolder %s is not exists, skip hooks installation.\033[0m", scriptsFolderPath))
-		return nil
+		scriptsFolderPath := fmt.Sprintf("%s/%s", svcPath, scriptsFolder)
+		if Pc.FileExists(scriptsFolderPath) == false {
+			_, _ = Pc.Println(fmt.Sprintf("\033[0;33mFolder %s is not exists, skip hooks installation.\033[0m", scriptsFolderPath))
+			return nil
+		}
+
+		var err error
+		usedHooks, err = usedHookNames(svcPath, scriptsFolder)
+		if err != nil {
+			return err
+		}
+	} else if len(configuredHooks) == 0 {
+		return errors.New("hooks folder is not specified and component has no hooks in workspace config")
 	}
 
 	for _, hookName := range hookNames {
 		scriptPath := fmt.Sprintf("%s/%s", hooksPath, hookName)
-		scriptContent := fmt.Sprintf(hookScript, elcBinary, scriptsFolder, hookName)
+		if !contains(usedHooks, hookName) {
+			err := removeElcHook(options, scriptPath)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		scriptContent := renderHookScript(elcBinary, scriptsFolder, hookName)
 
 		var filePermissions os.FileMode = 0775
 
-		if Pc.FileExists(scriptPath) == false {
-			if options.Debug {
-				_, _ = Pc.Printf("touch %s\n", scriptPath)
-				_, _ = Pc.Printf("chmod %s %s\n", filePermissions, scriptPath)
+		if Pc.FileExists(scriptPath) {
+			isElc, err := isElcHook(scriptPath)
+			if err != nil {
+				return err
 			}
-
-			if !options.DryRun {
-				err := Pc.CreateFile(scriptPath)
-				if err != nil {
-					return err
+			if !isElc {
+				if Pc.FileExists(scriptPath + chainedHookSuffix) {
+					return errors.New(fmt.Sprintf("can not backup %s, file %s already exists", scriptPath, scriptPath+chainedHookSuffix))
 				}
-
-				err = Pc.Chmod(scriptPath, filePermissions)
+				err = moveFile(options, scriptPath, scriptPath+chainedHookSuffix)
 				if err != nil {
 					return err
 				}
@@ -112,6 +271,7 @@ func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary strin
 
 		if options.Debug {
 			_, _ = Pc.Printf("echo \"<script>\" > %s\n", scriptPath)
+			_, _ = Pc.Printf("chmod %s %s\n", filePermissions, scriptPath)
 		}
 
 		if !options.DryRun {
@@ -119,6 +279,11 @@ func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary strin
 			if err != nil {
 				return err
 			}
+
+			err = Pc.Chmod(scriptPath, filePermissions)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -126,3 +291,71 @@ func GenerateHookScripts(options *GlobalOptions, svcPath string, elcBinary strin
 
 	return nil
 }
+
+func RemoveHookScripts(options *GlobalOptions, svcPath string) error {
+	hooksPath := fmt.Sprintf("%s/.git/hooks", svcPath)
+	if Pc.FileExists(hooksPath) == false {
+		return nil
+	}
+
+	for _, hookName := range hookNames {
+		err := removeElcHook(options, fmt.Sprintf("%s/%s", hooksPath, hookName))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _ = Pc.Println(fmt.Sprintf("\033[0;32mHooks of elc removed from %s.\033[0m", hooksPath))
+
+	return nil
+}
+
+// CheckHookScripts compares installed hooks with hooks which would be generated now and returns list of differences.
+func CheckHookScripts(svcPath string, elcBinary string, scriptsFolder string, configuredHooks []string) ([]string, error) {
+	hooksPath := fmt.Sprintf("%s/.git/hooks", svcPath)
+
+	usedHooks := configuredHooks
+	if scriptsFolder != "" {
+		scriptsFolder = normalizeScriptsFolder(scriptsFolder)
+
+		var err error
+		usedHooks, err = usedHookNames(svcPath, scriptsFolder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []string
+	for _, hookName := range hookNames {
+		scriptPath := fmt.Sprintf("%s/%s", hooksPath, hookName)
+		exists := Pc.FileExists(scriptPath)
+
+		if !contains(usedHooks, hookName) {
+			if exists {
+				isElc, err := isElcHook(scriptPath)
+				if err != nil {
+					return nil, err
+				}
+				if isElc {
+					problems = append(problems, fmt.Sprintf("hook %s is not used anymore", hookName))
+				}
+			}
+			continue
+		}
+
+		if !exists {
+			problems = append(problems, fmt.Sprintf("hook %s is not installed", hookName))
+			continue
+		}
+
+		data, err := Pc.ReadFile(scriptPath)
+		if err != nil {
+			return nil, err
+		}
+		if string(data) != renderHookScript(elcBinary, scriptsFolder, hookName) {
+			problems = append(problems, fmt.Sprintf("hook %s is outdated", hookName))
+		}
+	}
+
+	return problems, nil
+}
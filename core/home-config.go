@@ -4,24 +4,45 @@ import (
 	"errors"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"io"
 	"strings"
 )
 
+// HomeSettings are personal settings of developer, defaults are used for all workspaces and can be overridden for every workspace.
+type HomeSettings struct {
+	Mode           string        `yaml:"mode,omitempty"`
+	ComposeRuntime string        `yaml:"compose_runtime,omitempty"`
+	Variables      yaml.MapSlice `yaml:"variables,omitempty"`
+}
+
+type HomeDefaults struct {
+	HomeSettings `yaml:",inline"`
+	// default values of flags by command, eg. "start": {"force": "true"}, explicitly passed flags take precedence
+	Flags map[string]map[string]string `yaml:"flags,omitempty"`
+}
+
 type HomeConfigItem struct {
-	Name     string `yaml:"name"`
-	Path     string `yaml:"path"`
-	RootPath string `yaml:"root_path"`
+	Name         string `yaml:"name"`
+	Path         string `yaml:"path"`
+	RootPath     string `yaml:"root_path"`
+	HomeSettings `yaml:",inline"`
 }
 
+// version of ~/.elc.yaml format, it is increased when stored data must be migrated
+const homeConfigVersion = 1
+
 type HomeConfig struct {
 	Path             string           `yaml:"-"`
+	Version          int              `yaml:"version"`
 	CurrentWorkspace string           `yaml:"current_workspace"`
-	UpdateCommand    string           `yaml:"update_command"`
+	UpdateCommand    string           `yaml:"update_command,omitempty"` // deprecated, elc updates itself
+	UpdateMirror     string           `yaml:"update_mirror,omitempty"`
+	UpdatePublicKey  string           `yaml:"update_public_key,omitempty"`
+	UpdateBinaryPath string           `yaml:"update_binary_path,omitempty"`
+	Defaults         HomeDefaults     `yaml:"defaults,omitempty"`
 	Workspaces       []HomeConfigItem `yaml:"workspaces"`
 }
 
-const DefaultUpdateCommand = "curl -sSL https://raw.githubusercontent.com/ensi-platform/elc/master/get.sh | sudo -E bash"
-
 func LoadHomeConfig(configPath string) (*HomeConfig, error) {
 	yamlFile, err := Pc.ReadFile(configPath)
 	if err != nil {
@@ -31,36 +52,132 @@ func LoadHomeConfig(configPath string) (*HomeConfig, error) {
 	cfg := &HomeConfig{}
 	err = yaml.Unmarshal(yamlFile, cfg)
 	if err != nil {
+		if Pc.FileExists(configPath + ".bak") {
+			return nil, errors.New(fmt.Sprintf("%s is broken: %s, previous version is saved in %s", configPath, err, configPath+".bak"))
+		}
 		return nil, err
 	}
 	cfg.Path = configPath
+
+	err = cfg.migrate()
+	if err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// migrate converts config written by older elc to the current format, it is saved in new format on the next change.
+func (hc *HomeConfig) migrate() error {
+	if hc.Version > homeConfigVersion {
+		return errors.New(fmt.Sprintf("%s has format version %d, but this elc supports version %d, please update elc", hc.Path, hc.Version, homeConfigVersion))
+	}
+
+	if hc.Version < 1 {
+		// update_command was used by 'elc update' before elc could update itself
+		hc.UpdateCommand = ""
+	}
+
+	hc.Version = homeConfigVersion
+
+	return nil
+}
+
+// SaveHomeConfig replaces config atomically: data is written to temporary file which is renamed over config,
+// so a crash never leaves truncated config. Previous content is kept in .bak file.
 func SaveHomeConfig(homeConfig *HomeConfig) error {
+	homeConfig.Version = homeConfigVersion
 	data, err := yaml.Marshal(homeConfig)
 	if err != nil {
 		return err
 	}
 
-	err = Pc.WriteFile(homeConfig.Path, data, 0644)
+	if Pc.FileExists(homeConfig.Path) {
+		previous, err := Pc.ReadFile(homeConfig.Path)
+		if err != nil {
+			return err
+		}
+		err = Pc.WriteFile(homeConfig.Path+".bak", previous, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmpPath := homeConfig.Path + ".tmp"
+	err = Pc.WriteFile(tmpPath, data, 0644)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return Pc.Rename(tmpPath, homeConfig.Path)
+}
+
+func lockHomeConfig(configPath string) (io.Closer, error) {
+	lock, err := Pc.LockFile(configPath + ".lock")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("can not lock %s: %s", configPath, err))
+	}
+
+	return lock, nil
 }
 
 func CheckHomeConfigIsEmpty(configPath string) error {
 	if Pc.FileExists(configPath) {
 		return nil
 	}
-	return SaveHomeConfig(&HomeConfig{Path: configPath, UpdateCommand: DefaultUpdateCommand})
+
+	lock, err := lockHomeConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	// config may be created by another process while we waited for lock
+	if Pc.FileExists(configPath) {
+		return nil
+	}
+
+	return SaveHomeConfig(&HomeConfig{Path: configPath})
+}
+
+// UpdateHomeConfig loads, changes and saves home config holding the lock,
+// so parallel elc processes do not overwrite changes of each other.
+func UpdateHomeConfig(update func(hc *HomeConfig) error) error {
+	configPath, err := homeConfigPath()
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockHomeConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	hc := &HomeConfig{Path: configPath}
+	if Pc.FileExists(configPath) {
+		hc, err = LoadHomeConfig(configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = update(hc)
+	if err != nil {
+		return err
+	}
+
+	return SaveHomeConfig(hc)
 }
 
 func (hc *HomeConfig) AddWorkspace(name string, path string) error {
+	if hc.FindWorkspace(name) != nil {
+		return errors.New(fmt.Sprintf("workspace with name '%s' already exists", name))
+	}
+
 	hc.Workspaces = append(hc.Workspaces, HomeConfigItem{Name: name, Path: path})
-	return SaveHomeConfig(hc)
+
+	return nil
 }
 
 func (hc *HomeConfig) RemoveWorkspace(name string) error {
@@ -76,7 +193,8 @@ func (hc *HomeConfig) RemoveWorkspace(name string) error {
 	}
 
 	hc.Workspaces = append(hc.Workspaces[:foundWsIndex], hc.Workspaces[foundWsIndex+1:]...)
-	return SaveHomeConfig(hc)
+
+	return nil
 }
 
 func (hc *HomeConfig) GetCurrentWorkspace(wsName string) (*HomeConfigItem, error) {
@@ -142,3 +260,40 @@ func (hc *HomeConfig) FindWorkspace(name string) *HomeConfigItem {
 
 	return nil
 }
+
+// Settings returns defaults overridden by settings of workspace, variables of workspace are added after default ones.
+func (hc *HomeConfig) Settings(hci *HomeConfigItem) HomeSettings {
+	result := HomeSettings{
+		Mode:           hc.Defaults.Mode,
+		ComposeRuntime: hc.Defaults.ComposeRuntime,
+		Variables:      stringifyMapSlice(hc.Defaults.Variables),
+	}
+	if hci == nil {
+		return result
+	}
+
+	if hci.Mode != "" {
+		result.Mode = hci.Mode
+	}
+	if hci.ComposeRuntime != "" {
+		result.ComposeRuntime = hci.ComposeRuntime
+	}
+	result.Variables = append(result.Variables, stringifyMapSlice(hci.Variables)...)
+
+	return result
+}
+
+// stringifyMapSlice converts values like USER_ID: 1000 to strings, as variables of workspace are always strings.
+// Variable without value is an empty string.
+func stringifyMapSlice(items yaml.MapSlice) yaml.MapSlice {
+	result := make(yaml.MapSlice, 0, len(items))
+	for _, item := range items {
+		value := ""
+		if item.Value != nil {
+			value = fmt.Sprint(item.Value)
+		}
+		result = append(result, yaml.MapItem{Key: fmt.Sprint(item.Key), Value: value})
+	}
+
+	return result
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// name of pseudo workspace for 'workspace select', it can not be used as name of real workspace
+const autoWorkspaceName = "auto"
+
+// WorkspaceProblem is a problem of registered workspace found by 'workspace doctor'.
+type WorkspaceProblem struct {
+	Workspace string `json:"workspace" yaml:"workspace"`
+	Problem   string `json:"problem" yaml:"problem"`
+}
+
+// NormalizePath returns absolute clean path, relative paths are resolved from current directory, ~ means home directory.
+func NormalizePath(value string) (string, error) {
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		homeDir, err := Pc.HomeDir()
+		if err != nil {
+			return "", err
+		}
+		value = path.Join(homeDir, strings.TrimPrefix(value, "~"))
+	}
+
+	if !path.IsAbs(value) {
+		cwd, err := Pc.Getwd()
+		if err != nil {
+			return "", err
+		}
+		value = path.Join(cwd, value)
+	}
+
+	return path.Clean(value), nil
+}
+
+func CheckWorkspaceName(name string) error {
+	if name == "" {
+		return errors.New("name of workspace can not be empty")
+	}
+	if name == autoWorkspaceName {
+		return errors.New(fmt.Sprintf("name '%s' is reserved for automatic selection of workspace", autoWorkspaceName))
+	}
+
+	return nil
+}
+
+func CheckWorkspacePath(wsPath string) error {
+	if !Pc.FileExists(path.Join(wsPath, workspaceConfigName)) {
+		return errors.New(fmt.Sprintf("%s is not found in %s", workspaceConfigName, wsPath))
+	}
+
+	return nil
+}
+
+func (hc *HomeConfig) RenameWorkspace(name string, newName string) error {
+	hci := hc.FindWorkspace(name)
+	if hci == nil {
+		return errors.New(fmt.Sprintf("workspace with name '%s' is not defined", name))
+	}
+	err := CheckWorkspaceName(newName)
+	if err != nil {
+		return err
+	}
+	if hc.FindWorkspace(newName) != nil {
+		return errors.New(fmt.Sprintf("workspace with name '%s' already exists", newName))
+	}
+
+	hci.Name = newName
+	if hc.CurrentWorkspace == name {
+		hc.CurrentWorkspace = newName
+	}
+
+	return nil
+}
+
+// MoveWorkspace changes path of workspace. If rootPath is empty and root path of workspace is set,
+// it is moved together with workspace, eg. /old/project with workspace in /old/project/ws moved to /new/project/ws gets root /new/project.
+func (hc *HomeConfig) MoveWorkspace(name string, newPath string, rootPath string) error {
+	hci := hc.FindWorkspace(name)
+	if hci == nil {
+		return errors.New(fmt.Sprintf("workspace with name '%s' is not defined", name))
+	}
+
+	if rootPath == "" && hci.RootPath != "" {
+		oldRoot := strings.TrimRight(hci.RootPath, "/")
+		oldPath := strings.TrimRight(hci.Path, "/")
+		suffix := strings.TrimPrefix(oldPath, oldRoot)
+		if !strings.HasPrefix(oldPath+"/", oldRoot+"/") || !strings.HasSuffix(newPath, suffix) {
+			return errors.New(fmt.Sprintf("can not move root path %s together with workspace, pass new root path explicitly", hci.RootPath))
+		}
+		rootPath = strings.TrimSuffix(newPath, suffix)
+	}
+
+	hci.Path = newPath
+	hci.RootPath = rootPath
+
+	return nil
+}
+
+// Problems returns problems of registered workspaces: broken paths, duplicates and bad current workspace.
+func (hc *HomeConfig) Problems() []WorkspaceProblem {
+	result := make([]WorkspaceProblem, 0)
+	add := func(workspace string, format string, a ...interface{}) {
+		result = append(result, WorkspaceProblem{Workspace: workspace, Problem: fmt.Sprintf(format, a...)})
+	}
+
+	names := make(map[string]bool)
+	paths := make(map[string]string)
+	for _, hci := range hc.Workspaces {
+		err := CheckWorkspaceName(hci.Name)
+		if err != nil {
+			add(hci.Name, "%s", err)
+		}
+		if names[hci.Name] {
+			add(hci.Name, "name is registered more than once")
+		}
+		names[hci.Name] = true
+
+		if !path.IsAbs(hci.Path) {
+			add(hci.Name, "path %s is not absolute", hci.Path)
+		} else if err := CheckWorkspacePath(hci.Path); err != nil {
+			add(hci.Name, "%s", err)
+		}
+		if other, found := paths[path.Clean(hci.Path)]; found {
+			add(hci.Name, "path %s is registered by workspace '%s' too", hci.Path, other)
+		} else {
+			paths[path.Clean(hci.Path)] = hci.Name
+		}
+
+		if hci.RootPath != "" {
+			if !path.IsAbs(hci.RootPath) {
+				add(hci.Name, "root path %s is not absolute", hci.RootPath)
+			} else if !Pc.FileExists(hci.RootPath) {
+				add(hci.Name, "root path %s does not exist", hci.RootPath)
+			}
+		}
+	}
+
+	current := hc.CurrentWorkspace
+	if current != "" && current != autoWorkspaceName && !names[current] {
+		add(current, "current workspace '%s' is not registered", current)
+	}
+
+	return result
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// hooks which receive data from git via stdin, it is read once and passed to every script
+var stdinHookNames = []string{
+	"pre-push",
+	"pre-receive",
+	"post-receive",
+	"post-rewrite",
+	"reference-transaction",
+}
+
+// folder with hook scripts which is used when neither folder nor hooks in config are specified
+const defaultHooksFolder = ".git_hooks"
+
+type HookScript struct {
+	Name   string
+	Path   string
+	OnHost bool
+}
+
+// isHostScript checks script policy: scripts named like 'check.host.sh' are executed on host, others in container.
+func isHostScript(name string) bool {
+	return strings.Contains(name, ".host.") || strings.HasSuffix(name, ".host")
+}
+
+func findHookScripts(svcPath string, hooksFolder string, hookName string) ([]HookScript, error) {
+	relPath := path.Join(hooksFolder, hookName)
+	dirPath := path.Join(svcPath, relPath)
+	if !Pc.FileExists(dirPath) {
+		return nil, nil
+	}
+
+	files, err := Pc.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var scripts []HookScript
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		scripts = append(scripts, HookScript{
+			Name:   file.Name(),
+			Path:   "./" + path.Join(relPath, file.Name()),
+			OnHost: isHostScript(file.Name()),
+		})
+	}
+
+	return scripts, nil
+}
+
+// trimElcExec removes 'elc exec' prefix from hook command, because hook commands are already executed in container.
+func trimElcExec(command string) string {
+	command = strings.TrimSpace(command)
+	if strings.HasPrefix(command, "elc exec ") {
+		return strings.TrimSpace(strings.TrimPrefix(command, "elc exec "))
+	}
+
+	return command
+}
+
+// skipHooks checks ELC_SKIP_HOOKS variable: '1', 'true' or 'all' disables all hooks,
+// otherwise it is a comma separated list of hook names and script names.
+func skipHooks(names ...string) bool {
+	value, found := Pc.LookupEnv("ELC_SKIP_HOOKS")
+	if !found || value == "" {
+		return false
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "1" || item == "true" || item == "all" || contains(names, item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (comp *Component) hostComponent() (*Component, error) {
+	if comp.Config.HostedIn == "" {
+		return comp, nil
+	}
+
+	return comp.Workspace.ComponentByName(comp.Config.HostedIn)
+}
+
+func (comp *Component) RunHook(options *GlobalOptions, hooksFolder string, hookName string, args []string) error {
+	if skipHooks(hookName) {
+		_, _ = Pc.Printf("Hook %s is skipped by ELC_SKIP_HOOKS\n", hookName)
+		return nil
+	}
+
+	svcPath, found := comp.Context.find("SVC_PATH")
+	if !found {
+		return errors.New("path of component is not defined.Check workspace.yaml")
+	}
+
+	var err error
+	var scripts []HookScript
+	commands := comp.getHookCommands(hookName)
+	if hooksFolder == "" && len(commands) == 0 {
+		hooksFolder = defaultHooksFolder
+	}
+	if hooksFolder != "" {
+		hooksFolder = strings.Trim(strings.ReplaceAll(hooksFolder, "./", ""), "/")
+		scripts, err = findHookScripts(svcPath, hooksFolder, hookName)
+		if err != nil {
+			return err
+		}
+		commands = nil
+	}
+	if len(scripts) == 0 && len(commands) == 0 {
+		return nil
+	}
+
+	hookOptions := *options
+	hookOptions.Mode = "hook"
+	hookOptions.NoTty = true
+	if contains(stdinHookNames, hookName) {
+		hookOptions.Input, err = Pc.ReadStdin()
+		if err != nil {
+			return err
+		}
+	}
+
+	hostComp, err := comp.hostComponent()
+	if err != nil {
+		return err
+	}
+	if comp.Config.ExecPath != "" {
+		hookOptions.WorkingDir, err = comp.Context.RenderString(comp.Config.ExecPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, command := range commands {
+		hookOptions.Cmd = append([]string{"sh", "-c", trimElcExec(command), hookName}, args...)
+		code, err := hostComp.Exec(&hookOptions)
+		if err != nil {
+			return errors.New(fmt.Sprintf("hook command '%s' failed: %s", command, err))
+		}
+		if code != 0 {
+			return errors.New(fmt.Sprintf("hook command '%s' failed with exit code %d", command, code))
+		}
+	}
+
+	for _, script := range scripts {
+		if skipHooks(script.Name) {
+			_, _ = Pc.Printf("Script %s is skipped by ELC_SKIP_HOOKS\n", script.Name)
+			continue
+		}
+
+		var code int
+		if script.OnHost {
+			code, err = comp.execInteractive(append([]string{path.Join(svcPath, script.Path)}, args...), &hookOptions)
+		} else {
+			hookOptions.Cmd = append([]string{script.Path}, args...)
+			code, err = hostComp.Exec(&hookOptions)
+		}
+		if err != nil {
+			return errors.New(fmt.Sprintf("hook script %s failed: %s", script.Path, err))
+		}
+		if code != 0 {
+			return errors.New(fmt.Sprintf("hook script %s failed with exit code %d", script.Path, code))
+		}
+	}
+
+	return nil
+}
+
+// hooks of component lifecycle, they are configured in 'hooks' section together with git hooks
+var lifecycleHookNames = []string{
+	"before_start",
+	"after_start",
+	"before_stop",
+	"after_stop",
+	"after_clone",
+	"after_pull",
+}
+
+// prefix of lifecycle hook command which must be executed in container of component instead of host
+const containerHookPrefix = "exec:"
+
+// getLifecycleHookCommands returns commands of hook, after_clone_hook is used as after_clone hook for compatibility.
+func (comp *Component) getLifecycleHookCommands(hookName string) ([]string, error) {
+	commands := comp.getHookCommands(hookName)
+	if hookName != "after_clone" || len(commands) > 0 {
+		return commands, nil
+	}
+
+	afterCloneHook := comp.getAfterCloneHook()
+	if afterCloneHook == "" {
+		return nil, nil
+	}
+
+	afterCloneHook, err := comp.Context.RenderString(afterCloneHook)
+	if err != nil {
+		return nil, err
+	}
+	if afterCloneHook == "" {
+		return nil, nil
+	}
+
+	return []string{afterCloneHook}, nil
+}
+
+// runLifecycleHook executes commands of hook one by one with variables of component as environment.
+// Host commands are executed by shell in folder of component, commands with 'exec:' prefix are rendered with variables
+// of component and executed in its container, or in container of service which hosts it, if it is running.
+func (comp *Component) runLifecycleHook(options *GlobalOptions, hookName string) error {
+	commands, err := comp.getLifecycleHookCommands(hookName)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	if skipHooks(hookName) {
+		_, _ = Pc.Printf("Hook %s is skipped by ELC_SKIP_HOOKS\n", hookName)
+		return nil
+	}
+
+	for _, command := range commands {
+		var code int
+		if strings.HasPrefix(command, containerHookPrefix) {
+			if hookName == "before_start" || hookName == "after_stop" {
+				return errors.New(fmt.Sprintf("hook %s can not execute commands in container: %s", hookName, command))
+			}
+
+			var hostComp *Component
+			hostComp, err = comp.hostComponent()
+			if err != nil {
+				return err
+			}
+
+			// hook must not start service, so command is skipped when container is not running, eg. after clone
+			var running bool
+			running, err = hostComp.IsRunning(options)
+			if err != nil {
+				return err
+			}
+			if !running {
+				_, _ = Pc.Printf("Command '%s' of hook %s is skipped, service %s is not running\n", command, hookName, hostComp.Name)
+				continue
+			}
+
+			var containerCommand string
+			containerCommand, err = comp.Context.RenderString(strings.TrimSpace(strings.TrimPrefix(command, containerHookPrefix)))
+			if err != nil {
+				return errors.New(fmt.Sprintf("bad command of hook %s: %s", hookName, err))
+			}
+
+			execOptions := *options
+			execOptions.Cmd = []string{"sh", "-c", containerCommand}
+			code, err = hostComp.execInContainer(&execOptions)
+		} else {
+			code, err = comp.execInteractive([]string{"sh", "-c", fmt.Sprintf("cd \"$SVC_PATH\" && %s", command)}, options)
+		}
+		if err != nil {
+			return errors.New(fmt.Sprintf("hook %s failed: %s", hookName, err))
+		}
+		if code != 0 {
+			return errors.New(fmt.Sprintf("hook %s failed on command '%s' with exit code %d", hookName, command, code))
+		}
+	}
+
+	return nil
+}
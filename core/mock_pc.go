@@ -5,6 +5,7 @@
 package core
 
 import (
+	io "io"
 	os "os"
 	reflect "reflect"
 
@@ -90,6 +91,20 @@ func (mr *MockPCMockRecorder) CreateFile(filename interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockPC)(nil).CreateFile), filename)
 }
 
+// DialUnix mocks base method.
+func (m *MockPC) DialUnix(socketPath string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DialUnix", socketPath)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DialUnix indicates an expected call of DialUnix.
+func (mr *MockPCMockRecorder) DialUnix(socketPath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DialUnix", reflect.TypeOf((*MockPC)(nil).DialUnix), socketPath)
+}
+
 // ExecInteractive mocks base method.
 func (m *MockPC) ExecInteractive(command, env []string) (int, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +136,36 @@ func (mr *MockPCMockRecorder) ExecToString(command, env interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecToString", reflect.TypeOf((*MockPC)(nil).ExecToString), command, env)
 }
 
+// ExecWithInput mocks base method.
+func (m *MockPC) ExecWithInput(command, env []string, input []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecWithInput", command, env, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecWithInput indicates an expected call of ExecWithInput.
+func (mr *MockPCMockRecorder) ExecWithInput(command, env, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecWithInput", reflect.TypeOf((*MockPC)(nil).ExecWithInput), command, env, input)
+}
+
+// Executable mocks base method.
+func (m *MockPC) Executable() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Executable")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Executable indicates an expected call of Executable.
+func (mr *MockPCMockRecorder) Executable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Executable", reflect.TypeOf((*MockPC)(nil).Executable))
+}
+
 // Exit mocks base method.
 func (m *MockPC) Exit(code int) {
 	m.ctrl.T.Helper()
@@ -147,6 +192,20 @@ func (mr *MockPCMockRecorder) FileExists(filepath interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileExists", reflect.TypeOf((*MockPC)(nil).FileExists), filepath)
 }
 
+// Getgid mocks base method.
+func (m *MockPC) Getgid() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Getgid")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Getgid indicates an expected call of Getgid.
+func (mr *MockPCMockRecorder) Getgid() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Getgid", reflect.TypeOf((*MockPC)(nil).Getgid))
+}
+
 // Getuid mocks base method.
 func (m *MockPC) Getuid() int {
 	m.ctrl.T.Helper()
@@ -191,6 +250,34 @@ func (mr *MockPCMockRecorder) HomeDir() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HomeDir", reflect.TypeOf((*MockPC)(nil).HomeDir))
 }
 
+// IsDir mocks base method.
+func (m *MockPC) IsDir(filepath string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDir", filepath)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDir indicates an expected call of IsDir.
+func (mr *MockPCMockRecorder) IsDir(filepath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDir", reflect.TypeOf((*MockPC)(nil).IsDir), filepath)
+}
+
+// IsStdinTerminal mocks base method.
+func (m *MockPC) IsStdinTerminal() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsStdinTerminal")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsStdinTerminal indicates an expected call of IsStdinTerminal.
+func (mr *MockPCMockRecorder) IsStdinTerminal() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsStdinTerminal", reflect.TypeOf((*MockPC)(nil).IsStdinTerminal))
+}
+
 // IsTerminal mocks base method.
 func (m *MockPC) IsTerminal() bool {
 	m.ctrl.T.Helper()
@@ -205,6 +292,36 @@ func (mr *MockPCMockRecorder) IsTerminal() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTerminal", reflect.TypeOf((*MockPC)(nil).IsTerminal))
 }
 
+// LockFile mocks base method.
+func (m *MockPC) LockFile(filename string) (io.Closer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockFile", filename)
+	ret0, _ := ret[0].(io.Closer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockFile indicates an expected call of LockFile.
+func (mr *MockPCMockRecorder) LockFile(filename interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockFile", reflect.TypeOf((*MockPC)(nil).LockFile), filename)
+}
+
+// LookupEnv mocks base method.
+func (m *MockPC) LookupEnv(key string) (string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupEnv", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// LookupEnv indicates an expected call of LookupEnv.
+func (mr *MockPCMockRecorder) LookupEnv(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupEnv", reflect.TypeOf((*MockPC)(nil).LookupEnv), key)
+}
+
 // Printf mocks base method.
 func (m *MockPC) Printf(format string, a ...interface{}) (int, error) {
 	m.ctrl.T.Helper()
@@ -274,6 +391,93 @@ func (mr *MockPCMockRecorder) ReadFile(filename interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockPC)(nil).ReadFile), filename)
 }
 
+// ReadLine mocks base method.
+func (m *MockPC) ReadLine() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLine")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLine indicates an expected call of ReadLine.
+func (mr *MockPCMockRecorder) ReadLine() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLine", reflect.TypeOf((*MockPC)(nil).ReadLine))
+}
+
+// ReadStdin mocks base method.
+func (m *MockPC) ReadStdin() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStdin")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStdin indicates an expected call of ReadStdin.
+func (mr *MockPCMockRecorder) ReadStdin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStdin", reflect.TypeOf((*MockPC)(nil).ReadStdin))
+}
+
+// Remove mocks base method.
+func (m *MockPC) Remove(filename string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", filename)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockPCMockRecorder) Remove(filename interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockPC)(nil).Remove), filename)
+}
+
+// Rename mocks base method.
+func (m *MockPC) Rename(oldpath, newpath string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", oldpath, newpath)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockPCMockRecorder) Rename(oldpath, newpath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockPC)(nil).Rename), oldpath, newpath)
+}
+
+// Username mocks base method.
+func (m *MockPC) Username() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Username")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Username indicates an expected call of Username.
+func (mr *MockPCMockRecorder) Username() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Username", reflect.TypeOf((*MockPC)(nil).Username))
+}
+
+// WithCleanEnv mocks base method.
+func (m *MockPC) WithCleanEnv() PC {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithCleanEnv")
+	ret0, _ := ret[0].(PC)
+	return ret0
+}
+
+// WithCleanEnv indicates an expected call of WithCleanEnv.
+func (mr *MockPCMockRecorder) WithCleanEnv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithCleanEnv", reflect.TypeOf((*MockPC)(nil).WithCleanEnv))
+}
+
 // WriteFile mocks base method.
 func (m *MockPC) WriteFile(filename string, data []byte, perm os.FileMode) error {
 	m.ctrl.T.Helper()
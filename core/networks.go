@@ -0,0 +1,183 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// label of networks created by elc, value is a name of workspace
+const networkLabel = "elc.workspace"
+
+// NetworkConfig is a network of workspace, elc creates it before start of components and removes it with 'workspace destroy'.
+// Name defaults to key of network in workspace.yaml, all values may contain variables of workspace.
+type NetworkConfig struct {
+	Name    string `yaml:"name"`
+	Driver  string `yaml:"driver"`
+	Subnet  string `yaml:"subnet"`
+	Gateway string `yaml:"gateway"`
+}
+
+// Networks returns networks of workspace sorted by key with rendered values.
+func (ws *Workspace) Networks() ([]NetworkConfig, error) {
+	keys := make([]string, 0, len(ws.Config.Networks))
+	for key := range ws.Config.Networks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]NetworkConfig, 0, len(keys))
+	for _, key := range keys {
+		network := ws.Config.Networks[key]
+		if network.Name == "" {
+			network.Name = key
+		}
+
+		for _, value := range []*string{&network.Name, &network.Driver, &network.Subnet, &network.Gateway} {
+			rendered, err := ws.Context.RenderString(*value)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("bad network '%s': %s", key, err))
+			}
+			*value = rendered
+		}
+
+		result = append(result, network)
+	}
+
+	return result, nil
+}
+
+// existingNetworks returns names of networks of container engine, listing is used instead of inspect
+// because inspect prints error for every missing network. With ownOnly only networks created by elc
+// for this workspace are returned.
+func (ws *Workspace) existingNetworks(ownOnly bool) (map[string]bool, error) {
+	names, err := ws.listEngineResources(ws.networkListCommand(ownOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool)
+	for _, name := range names {
+		result[name] = true
+	}
+
+	return result, nil
+}
+
+func (ws *Workspace) networkListCommand(ownOnly bool) []string {
+	command := []string{"network", "ls"}
+	if ownOnly {
+		command = append(command, "--filter", fmt.Sprintf("label=%s=%s", networkLabel, ws.Config.Name))
+	}
+
+	return append(command, "--format", "{{.Name}}")
+}
+
+// networksBeforeChange returns existingNetworks before networks are created or removed, with --dry-run
+// listing command is only printed like other commands and nil is returned.
+func (ws *Workspace) networksBeforeChange(ownOnly bool, options *GlobalOptions) (map[string]bool, error) {
+	if options.DryRun {
+		if options.Debug {
+			command := append([]string{ws.containerEngine()}, ws.networkListCommand(ownOnly)...)
+			_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+		}
+		return nil, nil
+	}
+
+	return ws.existingNetworks(ownOnly)
+}
+
+// execEngineCommand runs command of container engine, it is printed with --debug and skipped with --dry-run.
+func (ws *Workspace) execEngineCommand(command []string, options *GlobalOptions) error {
+	if options.Debug {
+		_, _ = Pc.Printf(">> %s\n", strings.Join(command, " "))
+	}
+	if options.DryRun {
+		return nil
+	}
+
+	_, _, err := Pc.ExecToString(command, []string{})
+
+	return err
+}
+
+// EnsureNetworks creates missing networks of workspace, it is done once per run of elc.
+func (ws *Workspace) EnsureNetworks(options *GlobalOptions) error {
+	if ws.networksReady || len(ws.Config.Networks) == 0 {
+		return nil
+	}
+
+	networks, err := ws.Networks()
+	if err != nil {
+		return err
+	}
+	existing, err := ws.networksBeforeChange(false, options)
+	if err != nil {
+		return err
+	}
+
+	for _, network := range networks {
+		if existing[network.Name] {
+			continue
+		}
+
+		command := []string{ws.containerEngine(), "network", "create"}
+		if network.Driver != "" {
+			command = append(command, "--driver", network.Driver)
+		}
+		if network.Subnet != "" {
+			command = append(command, "--subnet", network.Subnet)
+		}
+		if network.Gateway != "" {
+			command = append(command, "--gateway", network.Gateway)
+		}
+		command = append(command, "--label", networkLabel+"="+ws.Config.Name, network.Name)
+
+		err = ws.execEngineCommand(command, options)
+		if err != nil {
+			return errors.New(fmt.Sprintf("can not create network %s: %s", network.Name, err))
+		}
+	}
+
+	ws.networksReady = true
+
+	return nil
+}
+
+// RemoveNetworks removes networks created by elc for this workspace, networks with the same name created by other tools
+// or workspaces are left as is. With --dry-run all networks of workspace are printed, because existing ones are not listed.
+func (ws *Workspace) RemoveNetworks(options *GlobalOptions) error {
+	if len(ws.Config.Networks) == 0 {
+		return nil
+	}
+
+	networks, err := ws.Networks()
+	if err != nil {
+		return err
+	}
+	existing, err := ws.networksBeforeChange(true, options)
+	if err != nil {
+		return err
+	}
+
+	failed := make([]string, 0)
+	for _, network := range networks {
+		if !options.DryRun && !existing[network.Name] {
+			continue
+		}
+
+		err = ws.execEngineCommand([]string{ws.containerEngine(), "network", "rm", network.Name}, options)
+		if err != nil {
+			failed = append(failed, network.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New(fmt.Sprintf("can not remove networks: %s", strings.Join(failed, ", ")))
+	}
+
+	ws.networksReady = false
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	OutputText = "text"
+	OutputJson = "json"
+	OutputYaml = "yaml"
+)
+
+// ComponentInfo is a description of component for machine-readable output, field names must not be changed.
+type ComponentInfo struct {
+	Name     string   `json:"name" yaml:"name"`
+	Type     string   `json:"type" yaml:"type"`
+	Path     string   `json:"path" yaml:"path"`
+	Tags     []string `json:"tags" yaml:"tags"`
+	Template string   `json:"template" yaml:"template"`
+	HostedIn string   `json:"hosted_in" yaml:"hosted_in"`
+	Aliases  []string `json:"aliases" yaml:"aliases"`
+	Cloned   bool     `json:"cloned" yaml:"cloned"`
+	Running  bool     `json:"running" yaml:"running"`
+}
+
+// WorkspaceInfo is a description of registered workspace for machine-readable output, field names must not be changed.
+type WorkspaceInfo struct {
+	Name     string `json:"name" yaml:"name"`
+	Path     string `json:"path" yaml:"path"`
+	RootPath string `json:"root_path" yaml:"root_path"`
+	Current  bool   `json:"current" yaml:"current"`
+}
+
+// WorkspaceStatus is a state of registered workspace for machine-readable output, field names must not be changed.
+type WorkspaceStatus struct {
+	Name    string   `json:"name" yaml:"name"`
+	Path    string   `json:"path" yaml:"path"`
+	Running []string `json:"running" yaml:"running"`
+	Error   string   `json:"error" yaml:"error"`
+}
+
+// WorkspacesStatus is an output of 'workspace status'.
+type WorkspacesStatus struct {
+	Workspaces []WorkspaceStatus    `json:"workspaces" yaml:"workspaces"`
+	Collisions []WorkspaceCollision `json:"collisions" yaml:"collisions"`
+}
+
+// VariableInfo is a variable of component for machine-readable output, a list is used to keep order of variables.
+type VariableInfo struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+func CheckOutputFormat(format string) error {
+	if format == "" || format == OutputText || format == OutputJson || format == OutputYaml {
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("unknown output format '%s', expected one of: text, json, yaml", format))
+}
+
+// PrintOutput prints data in json or yaml format, for text format printText is called.
+func PrintOutput(format string, data interface{}, printText func()) error {
+	var out []byte
+	var err error
+
+	switch format {
+	case "", OutputText:
+		printText()
+		return nil
+	case OutputJson:
+		out, err = json.MarshalIndent(data, "", "  ")
+	case OutputYaml:
+		out, err = yaml.Marshal(data)
+	default:
+		return CheckOutputFormat(format)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = Pc.Println(strings.TrimRight(string(out), "\n"))
+
+	return nil
+}
+
+func (comp *Component) Info(state ComponentState) ComponentInfo {
+	svcPath, _ := comp.Context.find("SVC_PATH")
+
+	aliases := make([]string, 0)
+	for alias, name := range comp.Workspace.Aliases {
+		if name == comp.Name {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	tags := make([]string, 0)
+	tags = append(tags, comp.Config.Tags...)
+
+	return ComponentInfo{
+		Name:     comp.Name,
+		Type:     comp.Config.Type(),
+		Path:     svcPath,
+		Tags:     tags,
+		Template: comp.Config.Extends,
+		HostedIn: comp.Config.HostedIn,
+		Aliases:  aliases,
+		Cloned:   state.Cloned,
+		Running:  state.Running,
+	}
+}
+
+func (comp *Component) VariablesInfo() []VariableInfo {
+	result := make([]VariableInfo, 0)
+	for _, pair := range *comp.Context {
+		result = append(result, VariableInfo{Name: pair[0], Value: comp.Workspace.Secrets.Mask(pair[1])})
+	}
+
+	return result
+}
+
+func (hc *HomeConfig) WorkspacesInfo(wsName string) []WorkspaceInfo {
+	current, _ := hc.GetCurrentWorkspace(wsName)
+
+	result := make([]WorkspaceInfo, 0)
+	for _, hci := range hc.Workspaces {
+		result = append(result, hci.Info(current != nil && current.Name == hci.Name))
+	}
+
+	return result
+}
+
+func (hci *HomeConfigItem) Info(current bool) WorkspaceInfo {
+	return WorkspaceInfo{
+		Name:     hci.Name,
+		Path:     hci.Path,
+		RootPath: hci.RootPath,
+		Current:  current,
+	}
+}
@@ -1,12 +1,18 @@
 package core
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/mattn/go-isatty"
 )
@@ -14,33 +20,84 @@ import (
 type PC interface {
 	ExecInteractive(command []string, env []string) (int, error)
 	ExecToString(command []string, env []string) (int, string, error)
+	ExecWithInput(command []string, env []string, input []byte) (int, error)
+	ReadStdin() ([]byte, error)
+	ReadLine() (string, error)
 	Args() []string
+	Executable() (string, error)
 	Exit(code int)
 	HomeDir() (string, error)
 	Getuid() int
+	Getgid() int
+	Username() (string, error)
 	Getwd() (dir string, err error)
+	LookupEnv(key string) (string, bool)
 	FileExists(filepath string) bool
+	IsDir(filepath string) bool
 	ReadFile(filename string) ([]byte, error)
 	ReadDir(dirname string) ([]os.FileInfo, error)
 	CreateFile(filename string) error
 	Chmod(filename string, mode os.FileMode) error
 	CreateDir(path string) error
 	WriteFile(filename string, data []byte, perm os.FileMode) error
+	Remove(filename string) error
+	Rename(oldpath string, newpath string) error
+	LockFile(filename string) (io.Closer, error)
+	DialUnix(socketPath string) error
 	Printf(format string, a ...interface{}) (n int, err error)
 	Println(a ...interface{}) (n int, err error)
 	IsTerminal() bool
+	IsStdinTerminal() bool
+	WithCleanEnv() PC
 }
 
 var Pc PC
 
-type RealPC struct{}
+type RealPC struct {
+	// CleanEnv disables inheritance of host environment by child processes, except of cleanEnvAllowlist
+	CleanEnv bool
+}
+
+// WithCleanEnv returns PC whose child processes get only passed variables and cleanEnvAllowlist of host environment.
+func (r *RealPC) WithCleanEnv() PC {
+	return &RealPC{CleanEnv: true}
+}
+
+// variables of host which are passed to child processes even with CleanEnv, without them docker and its plugins
+// can not find binaries, credentials and context; names ending with * are prefixes
+var cleanEnvAllowlist = []string{"PATH", "HOME", "DOCKER_*"}
+
+func isAllowedInCleanEnv(name string) bool {
+	for _, allowed := range cleanEnvAllowlist {
+		if name == allowed || strings.HasSuffix(allowed, "*") && strings.HasPrefix(name, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *RealPC) environ(env []string) []string {
+	if r.CleanEnv {
+		result := make([]string, 0, len(env))
+		for _, item := range os.Environ() {
+			name, _, _ := strings.Cut(item, "=")
+			if isAllowedInCleanEnv(name) {
+				result = append(result, item)
+			}
+		}
+		return append(result, env...)
+	}
+
+	return append(os.Environ(), env...)
+}
 
 func (r *RealPC) ExecInteractive(command []string, env []string) (int, error) {
 	cmd := exec.Command(command[0], command[1:]...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = r.environ(env)
 
 	err := cmd.Run()
 
@@ -52,16 +109,52 @@ func (r *RealPC) ExecToString(command []string, env []string) (int, string, erro
 	cmd := exec.Command(command[0], command[1:]...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = r.environ(env)
 
 	err := cmd.Run()
 	return cmd.ProcessState.ExitCode(), stdout.String(), err
 }
 
+func (r *RealPC) ExecWithInput(command []string, env []string, input []byte) (int, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = r.environ(env)
+
+	err := cmd.Run()
+
+	return cmd.ProcessState.ExitCode(), err
+}
+
+func (r *RealPC) ReadStdin() ([]byte, error) {
+	return ioutil.ReadAll(os.Stdin)
+}
+
+// ReadLine reads one line of user input without trailing line break.
+func (r *RealPC) ReadLine() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !(err == io.EOF && line != "") {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
 func (r *RealPC) Args() []string {
 	return os.Args
 }
 
+// Executable returns real path of running binary, symlinks are resolved.
+func (r *RealPC) Executable() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(executable)
+}
+
 func (r *RealPC) Exit(code int) {
 	os.Exit(code)
 }
@@ -79,16 +172,39 @@ func (r *RealPC) Getuid() int {
 	return os.Getuid()
 }
 
+func (r *RealPC) Getgid() int {
+	return os.Getgid()
+}
+
+func (r *RealPC) Username() (string, error) {
+	currentUser, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return currentUser.Username, nil
+}
+
 func (r *RealPC) Getwd() (dir string, err error) {
 	return os.Getwd()
 }
 
+func (r *RealPC) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
 func (r *RealPC) FileExists(filepath string) bool {
 	_, err := os.Stat(filepath)
 
 	return err == nil
 }
 
+func (r *RealPC) IsDir(filepath string) bool {
+	info, err := os.Stat(filepath)
+
+	return err == nil && info.IsDir()
+}
+
 func (r *RealPC) ReadFile(filename string) ([]byte, error) {
 	return ioutil.ReadFile(filename)
 }
@@ -119,6 +235,40 @@ func (r *RealPC) WriteFile(filename string, data []byte, perm os.FileMode) error
 	return ioutil.WriteFile(filename, data, perm)
 }
 
+func (r *RealPC) Remove(filename string) error {
+	return os.Remove(filename)
+}
+
+func (r *RealPC) Rename(oldpath string, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// LockFile takes exclusive advisory lock of file, waiting for other processes, the lock is released by Close.
+// DialUnix connects to unix socket and closes connection, it checks that socket is accessible and is listened.
+func (r *RealPC) DialUnix(socketPath string) error {
+	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func (r *RealPC) LockFile(filename string) (io.Closer, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockFile(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
 func (r *RealPC) Printf(format string, a ...interface{}) (n int, err error) {
 	return fmt.Printf(format, a...)
 }
@@ -130,3 +280,8 @@ func (r *RealPC) Println(a ...interface{}) (n int, err error) {
 func (r *RealPC) IsTerminal() bool {
 	return isatty.IsTerminal(os.Stdout.Fd())
 }
+
+// IsStdinTerminal checks that user can answer prompts, output of elc may be redirected meanwhile.
+func (r *RealPC) IsStdinTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	PruneContainer = "container"
+	PruneVolume    = "volume"
+	PruneImage     = "image"
+	PruneNetwork   = "network"
+)
+
+// label set by compose on containers, volumes, networks and built images of project
+const composeProjectLabel = "com.docker.compose.project"
+
+// PruneOptions selects resources removed by 'elc prune' besides containers, which are removed always.
+type PruneOptions struct {
+	Volumes  bool
+	Images   bool
+	Networks bool
+	// WorkspaceNetworks also removes networks from section 'networks' of workspace.yaml which are labelled by elc
+	// for this workspace, used when all services are pruned
+	WorkspaceNetworks bool
+}
+
+// PruneResource is a resource of container engine which will be removed by 'elc prune', field names must not be changed.
+type PruneResource struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Component string `json:"component" yaml:"component"`
+}
+
+// PrunePlan returns resources of compose projects of components, resources are found by label of compose project,
+// so orphan containers of removed services are found too. Modules are skipped, they have no containers.
+func (ws *Workspace) PrunePlan(compNames []string, pruneOptions PruneOptions) ([]PruneResource, error) {
+	result := make([]PruneResource, 0)
+	seen := make(map[string]bool)
+	add := func(kind string, name string, component string) {
+		if seen[kind+"/"+name] {
+			return
+		}
+		seen[kind+"/"+name] = true
+		result = append(result, PruneResource{Kind: kind, Name: name, Component: component})
+	}
+
+	for _, compName := range compNames {
+		comp, err := ws.ComponentByName(compName)
+		if err != nil {
+			return nil, err
+		}
+		if comp.Config.Type() != ComponentTypeService {
+			continue
+		}
+
+		projectName, _ := comp.Context.find("COMPOSE_PROJECT_NAME")
+		if projectName == "" {
+			continue
+		}
+		filter := fmt.Sprintf("label=%s=%s", composeProjectLabel, strings.ToLower(projectName))
+
+		listings := []struct {
+			kind    string
+			enabled bool
+			command []string
+		}{
+			{PruneContainer, true, []string{"ps", "-a", "--filter", filter, "--format", "{{.Names}}"}},
+			{PruneVolume, pruneOptions.Volumes, []string{"volume", "ls", "--filter", filter, "--format", "{{.Name}}"}},
+			{PruneImage, pruneOptions.Images, []string{"image", "ls", "--filter", filter, "--format", "{{.ID}} {{.Repository}}:{{.Tag}}"}},
+			{PruneNetwork, pruneOptions.Networks, []string{"network", "ls", "--filter", filter, "--format", "{{.Name}}"}},
+		}
+		for _, listing := range listings {
+			if !listing.enabled {
+				continue
+			}
+			names, err := ws.listEngineResources(listing.command)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("can not list %ss of component '%s': %s", listing.kind, compName, err))
+			}
+			for _, name := range names {
+				if listing.kind == PruneImage {
+					name = imageReference(name)
+				}
+				add(listing.kind, name, compName)
+			}
+		}
+	}
+
+	if pruneOptions.WorkspaceNetworks && len(ws.Config.Networks) > 0 {
+		networks, err := ws.Networks()
+		if err != nil {
+			return nil, err
+		}
+		existing, err := ws.existingNetworks(true)
+		if err != nil {
+			return nil, err
+		}
+		for _, network := range networks {
+			if existing[network.Name] {
+				add(PruneNetwork, network.Name, "")
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (ws *Workspace) listEngineResources(command []string) ([]string, error) {
+	_, out, err := Pc.ExecToString(append([]string{ws.containerEngine()}, command...), []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result, nil
+}
+
+// imageReference converts "ID REPOSITORY:TAG" to reference of image, dangling images have no name and are referenced by ID.
+func imageReference(line string) string {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) < 2 || strings.Contains(parts[1], "<none>") {
+		return parts[0]
+	}
+
+	return parts[1]
+}
+
+// Prune removes resources from PrunePlan, containers are removed first, because they hold volumes, images and networks.
+// Failure of one kind does not stop removal of others.
+func (ws *Workspace) Prune(resources []PruneResource, options *GlobalOptions) error {
+	commands := map[string][]string{
+		PruneContainer: {"rm", "-f"},
+		PruneVolume:    {"volume", "rm"},
+		PruneImage:     {"image", "rm"},
+		PruneNetwork:   {"network", "rm"},
+	}
+
+	failed := make([]string, 0)
+	for _, kind := range []string{PruneContainer, PruneVolume, PruneImage, PruneNetwork} {
+		names := make([]string, 0)
+		for _, resource := range resources {
+			if resource.Kind == kind {
+				names = append(names, resource.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+
+		command := append(append([]string{ws.containerEngine()}, commands[kind]...), names...)
+		err := ws.execEngineCommand(command, options)
+		if err != nil {
+			failed = append(failed, kind+"s")
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New(fmt.Sprintf("can not remove %s", strings.Join(failed, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type varDecl struct {
+	name    string
+	value   string
+	literal bool
+}
+
+// varResolver computes variables lazily, so a variable can reference another one declared later.
+// Declarations with the same name override previous ones, and a reference of a variable to itself
+// (eg. APPS_ROOT: ${APPS_ROOT:-/apps}) means the previous declaration or the fallback lookup.
+type varResolver struct {
+	decls     []varDecl
+	values    map[int]string
+	resolving map[int]bool
+	stack     []string
+	fallback  varLookup
+}
+
+func newVarResolver(fallback varLookup) *varResolver {
+	return &varResolver{
+		values:    make(map[int]string),
+		resolving: make(map[int]bool),
+		fallback:  fallback,
+	}
+}
+
+func (r *varResolver) add(name string, value string) {
+	r.decls = append(r.decls, varDecl{name: name, value: value})
+}
+
+func (r *varResolver) addLiteral(name string, value string) {
+	r.decls = append(r.decls, varDecl{name: name, value: value, literal: true})
+}
+
+func (r *varResolver) addContext(ctx Context) {
+	for _, pair := range ctx {
+		r.addLiteral(pair[0], pair[1])
+	}
+}
+
+func (r *varResolver) findDecl(name string, before int) int {
+	for i := before - 1; i >= 0; i-- {
+		if r.decls[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *varResolver) resolveDecl(index int) (string, error) {
+	if value, found := r.values[index]; found {
+		return value, nil
+	}
+
+	decl := r.decls[index]
+	if r.resolving[index] {
+		cycle := append(r.stack, decl.name)
+		return "", errors.New(fmt.Sprintf("variables have circular reference: %s", strings.Join(cycle, " -> ")))
+	}
+
+	value := decl.value
+	if !decl.literal {
+		r.resolving[index] = true
+		r.stack = append(r.stack, decl.name)
+
+		var err error
+		value, err = substVars(decl.value, r.lookupFrom(index))
+
+		r.stack = r.stack[:len(r.stack)-1]
+		delete(r.resolving, index)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	r.values[index] = value
+	return value, nil
+}
+
+func (r *varResolver) lookupFrom(index int) varLookup {
+	return func(name string) (string, bool, error) {
+		before := len(r.decls)
+		if index > -1 && r.decls[index].name == name {
+			before = index
+		}
+
+		found := r.findDecl(name, before)
+		if found == -1 {
+			if r.fallback == nil {
+				return "", false, nil
+			}
+			return r.fallback(name)
+		}
+
+		value, err := r.resolveDecl(found)
+		if err != nil {
+			return "", false, err
+		}
+		return value, true, nil
+	}
+}
+
+func (r *varResolver) RenderString(str string) (string, error) {
+	return substVars(str, r.lookupFrom(-1))
+}
+
+// context resolves all declarations and returns the final values in order of their last declaration.
+func (r *varResolver) context() (Context, error) {
+	ctx := make(Context, 0)
+	for index, decl := range r.decls {
+		if r.findDecl(decl.name, len(r.decls)) != index {
+			continue
+		}
+		value, err := r.resolveDecl(index)
+		if err != nil {
+			return nil, err
+		}
+		ctx = append(ctx, []string{decl.name, value})
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ComposeRuntimeDocker        = "docker"
+	ComposeRuntimeDockerCompose = "docker-compose"
+	ComposeRuntimePodman        = "podman"
+)
+
+func CheckComposeRuntime(runtime string) error {
+	switch runtime {
+	case "", ComposeRuntimeDocker, ComposeRuntimeDockerCompose, ComposeRuntimePodman:
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("unknown compose runtime '%s', expected one of: docker, docker-compose, podman", runtime))
+}
+
+// composeCommand returns command which runs compose with preferred runtime, docker compose plugin is used by default.
+func (ws *Workspace) composeCommand() []string {
+	switch ws.Settings.ComposeRuntime {
+	case ComposeRuntimeDockerCompose:
+		return []string{"docker-compose"}
+	case ComposeRuntimePodman:
+		return []string{"podman", "compose"}
+	default:
+		return []string{"docker", "compose"}
+	}
+}
+
+// containerEngine returns binary of container engine, standalone docker-compose works with docker.
+func (ws *Workspace) containerEngine() string {
+	if ws.Settings.ComposeRuntime == ComposeRuntimePodman {
+		return "podman"
+	}
+
+	return "docker"
+}
+
+// dependencyMode returns mode of dependencies: passed with --mode, then mode from ~/.elc.yaml, then 'default'.
+func (ws *Workspace) dependencyMode(mode string) string {
+	if mode != "" {
+		return mode
+	}
+	if ws.Settings.Mode != "" {
+		return ws.Settings.Mode
+	}
+
+	return "default"
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const secretMask = "******"
+
+type SecretSourceConfig struct {
+	Type     string `yaml:"type"`
+	Path     string `yaml:"path"`
+	Identity string `yaml:"identity"`
+	Command  string `yaml:"command"`
+}
+
+type SecretSource interface {
+	Lookup(name string) (string, bool, error)
+}
+
+// dotenvSecretSource reads secrets from a local KEY=VALUE file, which is usually ignored by git.
+type dotenvSecretSource struct {
+	path   string
+	values *Context
+}
+
+func (s *dotenvSecretSource) Lookup(name string) (string, bool, error) {
+	if s.values == nil {
+		values := make(Context, 0)
+		if Pc.FileExists(s.path) {
+			data, err := Pc.ReadFile(s.path)
+			if err != nil {
+				return "", false, err
+			}
+			values, err = parseDotenv(data)
+			if err != nil {
+				return "", false, errors.New(fmt.Sprintf("secrets file %s: %s", s.path, err))
+			}
+		}
+		s.values = &values
+	}
+
+	value, found := s.values.find(name)
+	return value, found, nil
+}
+
+// encryptedSecretSource decrypts a dotenv file with an external tool (sops, age) once and keeps the result in memory.
+type encryptedSecretSource struct {
+	path    string
+	command []string
+	values  *Context
+}
+
+func (s *encryptedSecretSource) Lookup(name string) (string, bool, error) {
+	if s.values == nil {
+		_, out, err := Pc.ExecToString(s.command, []string{})
+		if err != nil {
+			return "", false, errors.New(fmt.Sprintf("can not decrypt secrets file %s: %s", s.path, err))
+		}
+		values, err := parseDotenv([]byte(out))
+		if err != nil {
+			return "", false, errors.New(fmt.Sprintf("secrets file %s: %s", s.path, err))
+		}
+		s.values = &values
+	}
+
+	value, found := s.values.find(name)
+	return value, found, nil
+}
+
+// commandSecretSource asks an external password manager for every secret, the name is passed in ELC_SECRET_NAME.
+type commandSecretSource struct {
+	command string
+}
+
+func (s *commandSecretSource) Lookup(name string) (string, bool, error) {
+	_, out, err := Pc.ExecToString([]string{"sh", "-c", s.command}, []string{fmt.Sprintf("ELC_SECRET_NAME=%s", name)})
+	if err != nil {
+		return "", false, errors.New(fmt.Sprintf("can not get secret %s: %s", name, err))
+	}
+
+	out = strings.TrimRight(out, "\r\n")
+	if out == "" {
+		return "", false, nil
+	}
+
+	return out, true, nil
+}
+
+func newSecretSource(cfg SecretSourceConfig, ctx *Context) (SecretSource, error) {
+	secretPath, err := ctx.RenderString(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case "dotenv", "file":
+		return &dotenvSecretSource{path: secretPath}, nil
+	case "sops":
+		return &encryptedSecretSource{
+			path:    secretPath,
+			command: []string{"sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", secretPath},
+		}, nil
+	case "age":
+		command := []string{"age", "--decrypt"}
+		if cfg.Identity != "" {
+			identity, err := ctx.RenderString(cfg.Identity)
+			if err != nil {
+				return nil, err
+			}
+			command = append(command, "-i", identity)
+		}
+		return &encryptedSecretSource{path: secretPath, command: append(command, secretPath)}, nil
+	case "command":
+		if cfg.Command == "" {
+			return nil, errors.New("command of secret source is not defined")
+		}
+		return &commandSecretSource{command: cfg.Command}, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("unknown secret source type '%s'", cfg.Type))
+}
+
+type SecretStore struct {
+	sources  []SecretSource
+	resolved map[string]string
+}
+
+func NewSecretStore(configs []SecretSourceConfig, ctx *Context) (*SecretStore, error) {
+	store := &SecretStore{resolved: make(map[string]string)}
+	for _, cfg := range configs {
+		source, err := newSecretSource(cfg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		store.sources = append(store.sources, source)
+	}
+
+	return store, nil
+}
+
+func (ss *SecretStore) Get(name string) (string, error) {
+	if value, found := ss.resolved[name]; found {
+		return value, nil
+	}
+
+	for _, source := range ss.sources {
+		value, found, err := source.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			ss.resolved[name] = value
+			return value, nil
+		}
+	}
+
+	return "", errors.New(fmt.Sprintf("secret %s is not found", name))
+}
+
+// Mask hides values of all secrets resolved so far, so they don't leak to terminal or CI logs.
+func (ss *SecretStore) Mask(str string) string {
+	if ss == nil || len(ss.resolved) == 0 {
+		return str
+	}
+
+	values := make([]string, 0, len(ss.resolved))
+	for _, value := range ss.resolved {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	for _, value := range values {
+		str = strings.ReplaceAll(str, value, secretMask)
+	}
+
+	return str
+}
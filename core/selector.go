@@ -0,0 +1,163 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	selectorAll        = "all"
+	selectorTagPrefix  = "tag:"
+	selectorDepsPrefix = "deps-of:"
+	selectorNegation   = "!"
+)
+
+// SelectComponentNames resolves selector expressions to names of components.
+// Every expression is a comma separated list of terms: name or alias, glob ('catalog-*'), 'tag:NAME', 'deps-of:NAME' or 'all'.
+// Term prefixed with '!' excludes matched components, if there are only excluding terms they are subtracted from 'all'.
+// Names are returned in order of terms, components matched by one term are sorted.
+func (ws *Workspace) SelectComponentNames(expressions []string, options *GlobalOptions) ([]string, error) {
+	var included []string
+	excluded := make(map[string]bool)
+	hasIncludes := false
+
+	for _, expression := range expressions {
+		for _, term := range strings.Split(expression, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" || term == selectorNegation {
+				return nil, errors.New(fmt.Sprintf("empty term in selector '%s'", expression))
+			}
+
+			negative := strings.HasPrefix(term, selectorNegation)
+			term = strings.TrimPrefix(term, selectorNegation)
+
+			names, err := ws.selectByTerm(term, options)
+			if err != nil {
+				return nil, err
+			}
+
+			if !negative && len(names) == 0 {
+				return nil, errors.New(fmt.Sprintf("components matching '%s' not found", term))
+			}
+
+			if negative {
+				for _, name := range names {
+					excluded[name] = true
+				}
+			} else {
+				hasIncludes = true
+				included = append(included, names...)
+			}
+		}
+	}
+
+	if !hasIncludes {
+		included = ws.allComponentNames()
+	}
+
+	result := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range included {
+		if excluded[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New(fmt.Sprintf("selector '%s' matches no components", strings.Join(expressions, " ")))
+	}
+
+	return result, nil
+}
+
+func (ws *Workspace) selectByTerm(term string, options *GlobalOptions) ([]string, error) {
+	switch {
+	case term == selectorAll:
+		return ws.allComponentNames(), nil
+	case strings.HasPrefix(term, selectorTagPrefix):
+		tag := strings.TrimPrefix(term, selectorTagPrefix)
+		result := make([]string, 0)
+		for _, name := range ws.allComponentNames() {
+			if contains(ws.Components[name].Config.Tags, tag) {
+				result = append(result, name)
+			}
+		}
+		return result, nil
+	case strings.HasPrefix(term, selectorDepsPrefix):
+		comp, err := ws.ComponentByName(strings.TrimPrefix(term, selectorDepsPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return ws.dependencyNames(comp, options.Mode)
+	case strings.ContainsAny(term, "*?["):
+		result := make([]string, 0)
+		for _, name := range ws.allComponentNames() {
+			matched, err := path.Match(term, name)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("bad pattern '%s': %s", term, err))
+			}
+			if matched {
+				result = append(result, name)
+			}
+		}
+		return result, nil
+	default:
+		comp, err := ws.ComponentByName(term)
+		if err != nil {
+			return nil, err
+		}
+		return []string{comp.Name}, nil
+	}
+}
+
+// allComponentNames returns sorted names of all components except templates.
+func (ws *Workspace) allComponentNames() []string {
+	result := make([]string, 0)
+	for _, name := range ws.ComponentNames {
+		if !ws.Components[name].Config.IsTemplate {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// dependencyNames returns all direct and indirect dependencies of component for the mode, dependencies go first.
+func (ws *Workspace) dependencyNames(comp *Component, mode string) ([]string, error) {
+	mode = ws.dependencyMode(mode)
+	result := make([]string, 0)
+	visited := map[string]bool{comp.Name: true}
+
+	var walk func(c *Component) error
+	walk = func(c *Component) error {
+		for _, depName := range c.Config.GetDeps(mode) {
+			if visited[depName] {
+				continue
+			}
+			visited[depName] = true
+
+			depComp, found := ws.Components[depName]
+			if !found {
+				return errors.New(fmt.Sprintf("dependency with name '%s' is not defined", depName))
+			}
+			err := walk(depComp)
+			if err != nil {
+				return err
+			}
+			result = append(result, depName)
+		}
+
+		return nil
+	}
+
+	err := walk(comp)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// image of helper container which archives and extracts volumes
+const snapshotImage = "alpine:3"
+
+// label set by compose on volumes, value is a key of volume in compose file
+const composeVolumeLabel = "com.docker.compose.volume"
+
+const snapshotArchiveSuffix = ".tar.gz"
+
+// Snapshot is a saved state of named volumes of service, field names must not be changed.
+type Snapshot struct {
+	Component string   `json:"component" yaml:"component"`
+	Name      string   `json:"name" yaml:"name"`
+	Created   string   `json:"created" yaml:"created"`
+	Volumes   []string `json:"volumes" yaml:"volumes"`
+}
+
+// snapshotsDir returns folder of snapshots of component: ${WORKSPACE_PATH}/.elc/snapshots/<component>
+func (comp *Component) snapshotsDir() string {
+	return path.Join(comp.Workspace.ConfigPath, ".elc", "snapshots", comp.Name)
+}
+
+func (comp *Component) snapshotProject() (string, error) {
+	if comp.Config.Type() != ComponentTypeService {
+		return "", errors.New(fmt.Sprintf("component '%s' is not a service, snapshots are supported only for services", comp.Name))
+	}
+
+	projectName, _ := comp.Context.find("COMPOSE_PROJECT_NAME")
+	if projectName == "" {
+		return "", errors.New(fmt.Sprintf("COMPOSE_PROJECT_NAME of service '%s' is empty", comp.Name))
+	}
+
+	return strings.ToLower(projectName), nil
+}
+
+func checkSnapshotName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, "/\\") {
+		return errors.New(fmt.Sprintf("bad snapshot name '%s'", name))
+	}
+
+	return nil
+}
+
+// snapshotVolumes returns names of volumes archived in snapshot folder.
+func snapshotVolumes(snapshotPath string) ([]string, error) {
+	files, err := Pc.ReadDir(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), snapshotArchiveSuffix) {
+			result = append(result, strings.TrimSuffix(file.Name(), snapshotArchiveSuffix))
+		}
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// withStoppedService runs action while service is stopped, service is started again if it was running,
+// even if action failed. Error of action is returned first.
+func (comp *Component) withStoppedService(options *GlobalOptions, action func() error) error {
+	running, err := comp.IsRunning(options)
+	if err != nil {
+		return err
+	}
+
+	if running {
+		err = comp.Stop(options)
+		if err != nil {
+			return err
+		}
+	}
+
+	actionErr := action()
+
+	if running {
+		err = comp.Start(options)
+		if err != nil {
+			if actionErr != nil {
+				return errors.New(fmt.Sprintf("%s, service is not started again: %s", actionErr, err))
+			}
+			return err
+		}
+	}
+
+	return actionErr
+}
+
+// SaveSnapshot stops service and archives its named volumes into folder of snapshot, name defaults to current time.
+// Name of saved snapshot is returned.
+func (comp *Component) SaveSnapshot(name string, options *GlobalOptions) (string, error) {
+	projectName, err := comp.snapshotProject()
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	err = checkSnapshotName(name)
+	if err != nil {
+		return "", err
+	}
+
+	snapshotPath := path.Join(comp.snapshotsDir(), name)
+	if Pc.FileExists(snapshotPath) {
+		return "", errors.New(fmt.Sprintf("snapshot '%s' of service '%s' already exists, remove it with 'elc snapshot rm %s %s'", name, comp.Name, comp.Name, name))
+	}
+
+	volumes, err := comp.Workspace.listEngineResources([]string{"volume", "ls", "--filter", fmt.Sprintf("label=%s=%s", composeProjectLabel, projectName), "--format", "{{.Name}}"})
+	if err != nil {
+		return "", err
+	}
+	if len(volumes) == 0 {
+		return "", errors.New(fmt.Sprintf("service '%s' has no volumes, start it at least once", comp.Name))
+	}
+
+	err = comp.withStoppedService(options, func() error {
+		if !options.DryRun {
+			for _, dirPath := range []string{path.Dir(path.Dir(comp.snapshotsDir())), path.Dir(comp.snapshotsDir()), comp.snapshotsDir(), snapshotPath} {
+				if !Pc.FileExists(dirPath) {
+					err := Pc.CreateDir(dirPath)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for _, volume := range volumes {
+			err := comp.Workspace.execEngineCommand([]string{
+				comp.Workspace.containerEngine(), "run", "--rm",
+				"-v", volume + ":/volume:ro",
+				"-v", snapshotPath + ":/snapshot",
+				snapshotImage, "tar", "-czf", "/snapshot/" + volume + snapshotArchiveSuffix, "-C", "/volume", ".",
+			}, options)
+			if err != nil {
+				if !options.DryRun {
+					_ = removeSnapshotDir(snapshotPath)
+				}
+				return errors.New(fmt.Sprintf("can not archive volume %s: %s", volume, err))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// RestoreSnapshot stops service and replaces content of its volumes with archives of snapshot, missing volumes are created
+// with labels of compose, so compose uses them as its own.
+func (comp *Component) RestoreSnapshot(name string, options *GlobalOptions) error {
+	projectName, err := comp.snapshotProject()
+	if err != nil {
+		return err
+	}
+	err = checkSnapshotName(name)
+	if err != nil {
+		return err
+	}
+
+	snapshotPath := path.Join(comp.snapshotsDir(), name)
+	if !Pc.IsDir(snapshotPath) {
+		return errors.New(fmt.Sprintf("snapshot '%s' of service '%s' not found", name, comp.Name))
+	}
+	volumes, err := snapshotVolumes(snapshotPath)
+	if err != nil {
+		return err
+	}
+	if len(volumes) == 0 {
+		return errors.New(fmt.Sprintf("snapshot '%s' of service '%s' is empty", name, comp.Name))
+	}
+
+	existing, err := comp.Workspace.listEngineResources([]string{"volume", "ls", "--format", "{{.Name}}"})
+	if err != nil {
+		return err
+	}
+	existingVolumes := make(map[string]bool)
+	for _, volume := range existing {
+		existingVolumes[volume] = true
+	}
+
+	return comp.withStoppedService(options, func() error {
+		for _, volume := range volumes {
+			if !existingVolumes[volume] {
+				err := comp.Workspace.execEngineCommand([]string{
+					comp.Workspace.containerEngine(), "volume", "create",
+					"--label", composeProjectLabel + "=" + projectName,
+					"--label", composeVolumeLabel + "=" + strings.TrimPrefix(volume, projectName+"_"),
+					volume,
+				}, options)
+				if err != nil {
+					return errors.New(fmt.Sprintf("can not create volume %s: %s", volume, err))
+				}
+			}
+
+			err := comp.Workspace.execEngineCommand([]string{
+				comp.Workspace.containerEngine(), "run", "--rm",
+				"-v", volume + ":/volume",
+				"-v", snapshotPath + ":/snapshot:ro",
+				snapshotImage, "sh", "-c", "find /volume -mindepth 1 -delete && tar -xzf /snapshot/" + volume + snapshotArchiveSuffix + " -C /volume",
+			}, options)
+			if err != nil {
+				return errors.New(fmt.Sprintf("can not restore volume %s: %s", volume, err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// Snapshots returns saved snapshots of component sorted by name.
+func (comp *Component) Snapshots() ([]Snapshot, error) {
+	result := make([]Snapshot, 0)
+	if !Pc.IsDir(comp.snapshotsDir()) {
+		return result, nil
+	}
+
+	files, err := Pc.ReadDir(comp.snapshotsDir())
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+
+		volumes, err := snapshotVolumes(path.Join(comp.snapshotsDir(), file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Snapshot{
+			Component: comp.Name,
+			Name:      file.Name(),
+			Created:   file.ModTime().Format("2006-01-02 15:04:05"),
+			Volumes:   volumes,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// RemoveSnapshot removes archives and folder of snapshot.
+func (comp *Component) RemoveSnapshot(name string, options *GlobalOptions) error {
+	err := checkSnapshotName(name)
+	if err != nil {
+		return err
+	}
+
+	snapshotPath := path.Join(comp.snapshotsDir(), name)
+	if !Pc.IsDir(snapshotPath) {
+		return errors.New(fmt.Sprintf("snapshot '%s' of service '%s' not found", name, comp.Name))
+	}
+	if options.DryRun {
+		return nil
+	}
+
+	return removeSnapshotDir(snapshotPath)
+}
+
+func removeSnapshotDir(snapshotPath string) error {
+	files, err := Pc.ReadDir(snapshotPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		err = Pc.Remove(path.Join(snapshotPath, file.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return Pc.Remove(snapshotPath)
+}
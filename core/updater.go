@@ -0,0 +1,355 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// DefaultReleasesUrl is a base url of release index, mirrors must serve the same api:
+// {url}/releases/latest and {url}/releases/tags/{version}
+const DefaultReleasesUrl = "https://api.github.com/repos/ensi-platform/elc"
+
+const checksumsAssetName = "checksums.txt"
+
+// suffix of copy of replaced binary, it is used by 'elc update --rollback'
+const previousBinarySuffix = ".prev"
+
+// suffix of binary moved aside by rollback on Windows, where running executable can not be overwritten
+const replacedBinarySuffix = ".old"
+
+// running executable can not be overwritten or removed on Windows, but it can be renamed
+var isRunningBinaryLocked = runtime.GOOS == "windows"
+
+type releaseAsset struct {
+	Name        string `json:"name"`
+	DownloadUrl string `json:"browser_download_url"`
+}
+
+type releaseInfo struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+func (ri *releaseInfo) findAsset(name string) *releaseAsset {
+	for index, asset := range ri.Assets {
+		if asset.Name == name {
+			return &ri.Assets[index]
+		}
+	}
+
+	return nil
+}
+
+func releaseAssetName() string {
+	return fmt.Sprintf("elc_%s_%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// httpClient limits time of whole request, stalled mirror must not hang 'elc update'
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+func httpGet(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("request to %s failed with status %s", url, resp.Status))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// releaseTag converts version to name of release tag, so 1.2.3 and v1.2.3 are the same release.
+func releaseTag(ver string) string {
+	if ver == "" || strings.HasPrefix(ver, "v") {
+		return ver
+	}
+
+	return "v" + ver
+}
+
+func fetchRelease(releasesUrl string, ver string) (*releaseInfo, error) {
+	url := fmt.Sprintf("%s/releases/latest", strings.TrimRight(releasesUrl, "/"))
+	if ver != "" {
+		url = fmt.Sprintf("%s/releases/tags/%s", strings.TrimRight(releasesUrl, "/"), releaseTag(ver))
+	}
+
+	data, err := httpGet(url)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &releaseInfo{}
+	err = json.Unmarshal(data, release)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("bad release index %s: %s", url, err))
+	}
+
+	return release, nil
+}
+
+// verifyChecksum checks data against checksums file in format of sha256sum: '<hash>  <file name>' per line.
+func verifyChecksum(data []byte, checksums []byte, name string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(fields[0]) {
+			return errors.New(fmt.Sprintf("checksum of %s does not match", name))
+		}
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("checksum of %s is not found in %s", name, checksumsAssetName))
+}
+
+// verifySignature checks base64 encoded ed25519 signature of data.
+func verifySignature(data []byte, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("bad public key in update_public_key option of ~/.elc.yaml")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.New(fmt.Sprintf("bad signature: %s", err))
+	}
+
+	if !ed25519.Verify(key, data, sig) {
+		return errors.New("signature of binary is not valid")
+	}
+
+	return nil
+}
+
+func (hc *HomeConfig) releasesUrl() string {
+	if hc.UpdateMirror != "" {
+		return hc.UpdateMirror
+	}
+
+	return DefaultReleasesUrl
+}
+
+// updateTargetPath returns path of binary to replace: explicit path, path from config or path of running binary.
+func (hc *HomeConfig) updateTargetPath(targetPath string) (string, error) {
+	if targetPath != "" {
+		return targetPath, nil
+	}
+	if hc.UpdateBinaryPath != "" {
+		return hc.UpdateBinaryPath, nil
+	}
+
+	return Pc.Executable()
+}
+
+func isInstalledVersion(tagName string) bool {
+	vRelease, err := version.NewVersion(tagName)
+	if err != nil {
+		return false
+	}
+	vElc, err := version.NewVersion(Version)
+	if err != nil {
+		return false
+	}
+
+	return vRelease.Equal(vElc)
+}
+
+// downloadRelease downloads binary for current platform and verifies its checksum and signature.
+func downloadRelease(hc *HomeConfig, release *releaseInfo) ([]byte, error) {
+	assetName := releaseAssetName()
+	asset := release.findAsset(assetName)
+	if asset == nil {
+		return nil, errors.New(fmt.Sprintf("release %s has no binary %s", release.TagName, assetName))
+	}
+	checksumsAsset := release.findAsset(checksumsAssetName)
+	if checksumsAsset == nil {
+		return nil, errors.New(fmt.Sprintf("release %s has no %s, binary can not be verified", release.TagName, checksumsAssetName))
+	}
+
+	_, _ = Pc.Printf("Downloading %s\n", asset.DownloadUrl)
+	data, err := httpGet(asset.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums, err := httpGet(checksumsAsset.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyChecksum(data, checksums, assetName)
+	if err != nil {
+		return nil, err
+	}
+
+	if hc.UpdatePublicKey != "" {
+		sigAsset := release.findAsset(assetName + ".sig")
+		if sigAsset == nil {
+			return nil, errors.New(fmt.Sprintf("release %s has no signature %s.sig", release.TagName, assetName))
+		}
+		signature, err := httpGet(sigAsset.DownloadUrl)
+		if err != nil {
+			return nil, err
+		}
+		err = verifySignature(data, signature, hc.UpdatePublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// replaceBinary writes new binary next to target and renames it over target, so the binary is never half written.
+// Copy of replaced binary is kept with .prev suffix.
+func replaceBinary(targetPath string, data []byte) error {
+	newPath := targetPath + ".new"
+	err := Pc.WriteFile(newPath, data, 0755)
+	if err != nil {
+		return errors.New(fmt.Sprintf("can not write %s: %s. Use --path or update_binary_path option of ~/.elc.yaml to update binary in writable location", newPath, err))
+	}
+	err = Pc.Chmod(newPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	code, _, err := Pc.ExecToString([]string{newPath, "--version"}, []string{})
+	if err != nil || code != 0 {
+		_ = Pc.Remove(newPath)
+		return errors.New("downloaded binary is not executable")
+	}
+
+	if !Pc.FileExists(targetPath) {
+		return Pc.Rename(newPath, targetPath)
+	}
+
+	if isRunningBinaryLocked {
+		return replaceLockedBinary(targetPath, newPath)
+	}
+
+	err = savePreviousBinary(targetPath)
+	if err != nil {
+		return err
+	}
+
+	return Pc.Rename(newPath, targetPath)
+}
+
+// savePreviousBinary copies binary to .prev through temporary file, so interrupted update does not leave broken copy.
+func savePreviousBinary(targetPath string) error {
+	current, err := Pc.ReadFile(targetPath)
+	if err != nil {
+		return err
+	}
+
+	prevPath := targetPath + previousBinarySuffix
+	tmpPath := prevPath + ".tmp"
+	err = Pc.WriteFile(tmpPath, current, 0755)
+	if err != nil {
+		return err
+	}
+
+	return Pc.Rename(tmpPath, prevPath)
+}
+
+// replaceLockedBinary moves running binary to .prev and new binary to its place, running binary is moved back on failure.
+func replaceLockedBinary(targetPath string, newPath string) error {
+	_ = Pc.Remove(targetPath + replacedBinarySuffix)
+
+	prevPath := targetPath + previousBinarySuffix
+	err := Pc.Rename(targetPath, prevPath)
+	if err != nil {
+		return err
+	}
+
+	err = Pc.Rename(newPath, targetPath)
+	if err != nil {
+		_ = Pc.Rename(prevPath, targetPath)
+		return err
+	}
+
+	return nil
+}
+
+func UpdateBinary(hc *HomeConfig, ver string, targetPath string) error {
+	targetPath, err := hc.updateTargetPath(targetPath)
+	if err != nil {
+		return err
+	}
+
+	release, err := fetchRelease(hc.releasesUrl(), ver)
+	if err != nil {
+		return err
+	}
+
+	if isInstalledVersion(release.TagName) {
+		_, _ = Pc.Printf("elc %s is already installed\n", Version)
+		return nil
+	}
+
+	data, err := downloadRelease(hc, release)
+	if err != nil {
+		return err
+	}
+
+	err = replaceBinary(targetPath, data)
+	if err != nil {
+		return err
+	}
+
+	_, _ = Pc.Printf("elc %s is installed to %s\n", release.TagName, targetPath)
+
+	return nil
+}
+
+// RollbackBinary restores binary which was replaced by last update.
+func RollbackBinary(hc *HomeConfig, targetPath string) error {
+	targetPath, err := hc.updateTargetPath(targetPath)
+	if err != nil {
+		return err
+	}
+
+	prevPath := targetPath + previousBinarySuffix
+	if !Pc.FileExists(prevPath) {
+		return errors.New(fmt.Sprintf("previous binary %s is not found", prevPath))
+	}
+
+	if isRunningBinaryLocked && Pc.FileExists(targetPath) {
+		// binary is moved aside and removed by next update
+		replacedPath := targetPath + replacedBinarySuffix
+		_ = Pc.Remove(replacedPath)
+		err = Pc.Rename(targetPath, replacedPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = Pc.Rename(prevPath, targetPath)
+	if err != nil {
+		return err
+	}
+
+	_, _ = Pc.Printf("Previous binary is restored to %s\n", targetPath)
+
+	return nil
+}
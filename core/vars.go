@@ -0,0 +1,390 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variable language:
+//
+//	$NAME, ${NAME}          value of variable, error if it is not set
+//	${ns:NAME}              value from namespace, eg. ${secret:TOKEN}
+//	${NAME:-word}           word if NAME is unset or empty (${NAME-word} - only if unset)
+//	${NAME:+word}           word if NAME is set and not empty (${NAME+word} - if set)
+//	${NAME:?message}        error with message if NAME is unset or empty (${NAME?message} - if unset)
+//	${fn(arg, ...)}         call of function: lower, upper, replace, default
+//	$$                      literal $
+//
+// Words and arguments can contain nested expressions, "\" escapes next character inside braces.
+
+// namespaces of ${ns:NAME} references, they are resolved by lookup of workspace
+var varNamespaces = []string{"env", "secret"}
+
+type VarError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *VarError) Error() string {
+	return fmt.Sprintf("%s (position %d in '%s')", e.Msg, e.Pos+1, e.Expr)
+}
+
+type varNode interface {
+	eval(lookup varLookup) (string, error)
+}
+
+type varNodes []varNode
+
+func (nodes varNodes) eval(lookup varLookup) (string, error) {
+	var sb strings.Builder
+	for _, node := range nodes {
+		value, err := node.eval(lookup)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+	}
+
+	return sb.String(), nil
+}
+
+type textNode string
+
+func (n textNode) eval(_ varLookup) (string, error) {
+	return string(n), nil
+}
+
+type refNode struct {
+	expr string
+	pos  int
+	name string
+	op   string
+	word varNodes
+}
+
+func (n *refNode) eval(lookup varLookup) (string, error) {
+	value, found, err := lookup(n.name)
+	if err != nil {
+		return "", err
+	}
+	isEmpty := !found || value == ""
+
+	switch n.op {
+	case "":
+		if !found {
+			return "", &VarError{Expr: n.expr, Pos: n.pos, Msg: fmt.Sprintf("variable %s is not set", n.name)}
+		}
+		return value, nil
+	case ":-", "-":
+		if !found || (n.op == ":-" && isEmpty) {
+			return n.word.eval(lookup)
+		}
+		return value, nil
+	case ":+", "+":
+		if !found || (n.op == ":+" && isEmpty) {
+			return "", nil
+		}
+		return n.word.eval(lookup)
+	case ":?", "?":
+		if !found || (n.op == ":?" && isEmpty) {
+			msg, err := n.word.eval(lookup)
+			if err != nil {
+				return "", err
+			}
+			if msg == "" {
+				msg = "is not set"
+			}
+			return "", &VarError{Expr: n.expr, Pos: n.pos, Msg: fmt.Sprintf("variable %s: %s", n.name, msg)}
+		}
+		return value, nil
+	}
+
+	return "", &VarError{Expr: n.expr, Pos: n.pos, Msg: fmt.Sprintf("unknown operator '%s'", n.op)}
+}
+
+type varFunc struct {
+	minArgs int
+	maxArgs int
+	call    func(args []string) string
+}
+
+var varFuncs = map[string]varFunc{
+	"lower": {1, 1, func(args []string) string {
+		return strings.ToLower(args[0])
+	}},
+	"upper": {1, 1, func(args []string) string {
+		return strings.ToUpper(args[0])
+	}},
+	"replace": {3, 3, func(args []string) string {
+		return strings.ReplaceAll(args[0], args[1], args[2])
+	}},
+	"default": {2, -1, func(args []string) string {
+		for _, arg := range args {
+			if arg != "" {
+				return arg
+			}
+		}
+		return ""
+	}},
+}
+
+type callNode struct {
+	expr string
+	pos  int
+	name string
+	args []varNodes
+}
+
+func (n *callNode) eval(lookup varLookup) (string, error) {
+	fn := varFuncs[n.name]
+	args := make([]string, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(lookup)
+		if err != nil {
+			// default() is used exactly for unset variables, so they are treated as empty values
+			if _, isVarErr := err.(*VarError); isVarErr && n.name == "default" {
+				value = ""
+			} else {
+				return "", err
+			}
+		}
+		args = append(args, value)
+	}
+
+	return fn.call(args), nil
+}
+
+type varParser struct {
+	expr string
+	pos  int
+}
+
+func (p *varParser) errorf(pos int, format string, a ...interface{}) error {
+	return &VarError{Expr: p.expr, Pos: pos, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *varParser) eof() bool {
+	return p.pos >= len(p.expr)
+}
+
+func (p *varParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *varParser) parseIdent() string {
+	start := p.pos
+	if !p.eof() && isIdentStart(p.peek()) {
+		p.pos++
+		for !p.eof() && isIdentChar(p.peek()) {
+			p.pos++
+		}
+	}
+	return p.expr[start:p.pos]
+}
+
+// parseWord reads text with expressions until one of terminators is found on the top level.
+// If inBraces is false, terminators are ignored and backslash has no special meaning.
+func (p *varParser) parseWord(terminators string, inBraces bool) (varNodes, error) {
+	var nodes varNodes
+	var text strings.Builder
+	depth := 0
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, textNode(text.String()))
+			text.Reset()
+		}
+	}
+
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case inBraces && depth == 0 && strings.IndexByte(terminators, c) > -1:
+			flush()
+			return nodes, nil
+		case inBraces && c == '\\' && p.pos+1 < len(p.expr):
+			text.WriteByte(p.expr[p.pos+1])
+			p.pos += 2
+		case c == '$':
+			if p.pos+1 < len(p.expr) && p.expr[p.pos+1] == '$' {
+				text.WriteByte('$')
+				p.pos += 2
+				continue
+			}
+			node, err := p.parseDollar()
+			if err != nil {
+				return nil, err
+			}
+			if node == nil {
+				text.WriteByte('$')
+				continue
+			}
+			flush()
+			nodes = append(nodes, node)
+		case inBraces && (c == '{' || c == '('):
+			depth++
+			text.WriteByte(c)
+			p.pos++
+		case inBraces && depth > 0 && (c == '}' || c == ')'):
+			depth--
+			text.WriteByte(c)
+			p.pos++
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if inBraces {
+		return nil, p.errorf(p.pos, "unexpected end of expression, expected one of '%s'", terminators)
+	}
+
+	flush()
+	return nodes, nil
+}
+
+// parseDollar parses expression started with '$', returns nil if '$' is just a text.
+func (p *varParser) parseDollar() (varNode, error) {
+	start := p.pos
+	p.pos++
+
+	if isIdentStart(p.peek()) {
+		return &refNode{expr: p.expr, pos: start, name: p.parseIdent()}, nil
+	}
+
+	if p.peek() != '{' {
+		return nil, nil
+	}
+	p.pos++
+
+	name := p.parseIdent()
+	if name == "" {
+		return nil, p.errorf(p.pos, "variable name expected")
+	}
+
+	if p.peek() == ':' && p.pos+1 < len(p.expr) && isIdentStart(p.expr[p.pos+1]) {
+		if !contains(varNamespaces, name) {
+			return nil, p.errorf(start, "unknown namespace %s, expected one of %s", name, strings.Join(varNamespaces, ", "))
+		}
+		p.pos++
+		name = name + ":" + p.parseIdent()
+	}
+
+	if p.peek() == '(' {
+		return p.parseCall(start, name)
+	}
+
+	node := &refNode{expr: p.expr, pos: start, name: name}
+	if p.peek() == '}' {
+		p.pos++
+		return node, nil
+	}
+
+	opStart := p.pos
+	if p.peek() == ':' {
+		p.pos++
+	}
+	if p.eof() || strings.IndexByte("-+?", p.peek()) == -1 {
+		return nil, p.errorf(opStart, "unexpected character, expected one of ':-', '-', ':+', '+', ':?', '?' or '}'")
+	}
+	p.pos++
+	node.op = p.expr[opStart:p.pos]
+
+	word, err := p.parseWord("}", true)
+	if err != nil {
+		return nil, err
+	}
+	p.pos++
+	node.word = word
+
+	return node, nil
+}
+
+func (p *varParser) parseCall(start int, name string) (varNode, error) {
+	fn, found := varFuncs[name]
+	if !found {
+		return nil, p.errorf(start, "unknown function %s", name)
+	}
+	p.pos++
+
+	node := &callNode{expr: p.expr, pos: start, name: name}
+	for {
+		for p.peek() == ' ' {
+			p.pos++
+		}
+
+		var arg varNodes
+		if c := p.peek(); c == '"' || c == '\'' {
+			end := strings.IndexByte(p.expr[p.pos+1:], c)
+			if end == -1 {
+				return nil, p.errorf(p.pos, "unterminated string")
+			}
+			arg = varNodes{textNode(p.expr[p.pos+1 : p.pos+1+end])}
+			p.pos += end + 2
+			for p.peek() == ' ' {
+				p.pos++
+			}
+		} else {
+			var err error
+			arg, err = p.parseWord(",)", true)
+			if err != nil {
+				return nil, err
+			}
+			if n := len(arg); n > 0 {
+				if text, isText := arg[n-1].(textNode); isText {
+					arg[n-1] = textNode(strings.TrimRight(string(text), " "))
+				}
+			}
+		}
+		node.args = append(node.args, arg)
+
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf(p.pos, "expected ',' or ')' in arguments of %s", name)
+		}
+		p.pos++
+		break
+	}
+
+	if p.peek() != '}' {
+		return nil, p.errorf(p.pos, "expected '}' after call of %s", name)
+	}
+	p.pos++
+
+	if len(node.args) < fn.minArgs || (fn.maxArgs > -1 && len(node.args) > fn.maxArgs) {
+		return nil, p.errorf(start, "wrong number of arguments for %s: %d", name, len(node.args))
+	}
+
+	return node, nil
+}
+
+func parseVars(expr string) (varNodes, error) {
+	p := &varParser{expr: expr}
+	return p.parseWord("", false)
+}
+
+type varLookup func(name string) (string, bool, error)
+
+func substVars(expr string, lookup varLookup) (string, error) {
+	nodes, err := parseVars(expr)
+	if err != nil {
+		return "", err
+	}
+
+	return nodes.eval(lookup)
+}
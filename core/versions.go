@@ -0,0 +1,204 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// variable is set for binary started by version switching, so it never switches version again
+const versionSwitchedEnv = "ELC_VERSION_SWITCHED"
+
+// prefix of binaries in ~/.elc/versions, eg. elc-v1.4.2
+const versionBinaryPrefix = "elc-"
+
+func versionsDir() (string, error) {
+	homeDir, err := Pc.HomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(homeDir, ".elc", "versions"), nil
+}
+
+func createVersionsDir(dir string) error {
+	for _, dirPath := range []string{path.Dir(dir), dir} {
+		if !Pc.FileExists(dirPath) {
+			err := Pc.CreateDir(dirPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findCachedBinary returns path of the newest binary in dir which satisfies constraints, or empty string.
+func findCachedBinary(dir string, constraints version.Constraints) (string, error) {
+	if !Pc.FileExists(dir) {
+		return "", nil
+	}
+
+	files, err := Pc.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var found *version.Version
+	foundName := ""
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), versionBinaryPrefix) {
+			continue
+		}
+		v, err := version.NewVersion(strings.TrimPrefix(file.Name(), versionBinaryPrefix))
+		if err != nil || !constraints.Check(v) {
+			continue
+		}
+		if found == nil || v.GreaterThan(found) {
+			found = v
+			foundName = file.Name()
+		}
+	}
+
+	if foundName == "" {
+		return "", nil
+	}
+
+	return path.Join(dir, foundName), nil
+}
+
+func fetchReleases(releasesUrl string) ([]releaseInfo, error) {
+	url := fmt.Sprintf("%s/releases?per_page=100", strings.TrimRight(releasesUrl, "/"))
+	data, err := httpGet(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []releaseInfo
+	err = json.Unmarshal(data, &releases)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("bad release index %s: %s", url, err))
+	}
+
+	return releases, nil
+}
+
+// downloadVersion downloads the newest release which satisfies constraints to dir and returns path of binary.
+func downloadVersion(hc *HomeConfig, dir string, constraints version.Constraints) (string, error) {
+	releases, err := fetchReleases(hc.releasesUrl())
+	if err != nil {
+		return "", err
+	}
+
+	var found *version.Version
+	var release *releaseInfo
+	for index, ri := range releases {
+		v, err := version.NewVersion(ri.TagName)
+		if err != nil || !constraints.Check(v) {
+			continue
+		}
+		if found == nil || v.GreaterThan(found) {
+			found = v
+			release = &releases[index]
+		}
+	}
+	if release == nil {
+		return "", errors.New(fmt.Sprintf("release of elc matching version %s is not found", constraints))
+	}
+
+	data, err := downloadRelease(hc, release)
+	if err != nil {
+		return "", err
+	}
+
+	err = createVersionsDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	binaryPath := path.Join(dir, versionBinaryPrefix+"v"+found.String())
+	err = Pc.WriteFile(binaryPath, data, 0755)
+	if err != nil {
+		return "", err
+	}
+	err = Pc.Chmod(binaryPath, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	return binaryPath, nil
+}
+
+// pinnedVersionMatches checks that running binary satisfies elc_version of workspace, development build matches any version.
+func (ws *Workspace) pinnedVersionMatches() (version.Constraints, bool, error) {
+	constraints, err := version.NewConstraint(ws.Config.ElcVersion)
+	if err != nil {
+		return nil, false, errors.New(fmt.Sprintf("bad elc_version '%s': %s", ws.Config.ElcVersion, err))
+	}
+
+	vElc, err := version.NewVersion(Version)
+	if err != nil {
+		return constraints, true, nil
+	}
+
+	return constraints, constraints.Check(vElc), nil
+}
+
+// checkPinnedVersion is used instead of switchVersion when one command works with many workspaces.
+func (ws *Workspace) checkPinnedVersion() error {
+	_, matches, err := ws.pinnedVersionMatches()
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return errors.New(fmt.Sprintf("workspace requires elc version %s, but version %s is running, use --workspace to work with it", ws.Config.ElcVersion, Version))
+	}
+
+	return nil
+}
+
+// switchVersion re-executes current command with binary of version required by elc_version of workspace.
+// Binaries are cached in ~/.elc/versions, missing version is downloaded by updater.
+func (ws *Workspace) switchVersion(hc *HomeConfig) error {
+	constraints, matches, err := ws.pinnedVersionMatches()
+	if err != nil || matches {
+		return err
+	}
+
+	if _, found := Pc.LookupEnv(versionSwitchedEnv); found {
+		return errors.New(fmt.Sprintf("This workspace requires elc version %s, but binary of version %s is started.", ws.Config.ElcVersion, Version))
+	}
+
+	dir, err := versionsDir()
+	if err != nil {
+		return err
+	}
+
+	binaryPath, err := findCachedBinary(dir, constraints)
+	if err != nil {
+		return err
+	}
+	if binaryPath == "" {
+		_, _ = Pc.Printf("This workspace requires elc version %s, downloading it\n", ws.Config.ElcVersion)
+		binaryPath, err = downloadVersion(hc, dir, constraints)
+		if err != nil {
+			return err
+		}
+	}
+
+	command := append([]string{binaryPath}, Pc.Args()[1:]...)
+	// environment of host is inherited by ExecInteractive, only marker of switch is added
+	code, err := Pc.ExecInteractive(command, []string{fmt.Sprintf("%s=1", versionSwitchedEnv)})
+	if err != nil && code <= 0 {
+		return err
+	}
+
+	Pc.Exit(code)
+
+	return errors.New(fmt.Sprintf("elc is switched to %s", binaryPath))
+}
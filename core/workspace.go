@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-version"
@@ -16,7 +19,14 @@ type Workspace struct {
 	Cwd        string
 	WillStart  []string
 	Context    *Context
+	Secrets    *SecretStore
 	Components map[string]*Component
+	// sorted names of all components, maps are never iterated directly to keep order of actions stable
+	ComponentNames []string
+	// personal settings from ~/.elc.yaml
+	Settings HomeSettings
+	// networks of workspace are checked once per run
+	networksReady bool
 }
 
 func NewWorkspace(wsPath string, cwd string) *Workspace {
@@ -36,6 +46,9 @@ func (ws *Workspace) LoadConfig() error {
 		return err
 	}
 
+	// variables from ~/.elc.yaml override shared workspace.yaml, but not env.yaml of this workspace
+	wsc.Variables = append(wsc.Variables, ws.Settings.Variables...)
+
 	envPath := path.Join(ws.ConfigPath, "env.yaml")
 	if Pc.FileExists(envPath) {
 		envWsc := *NewWorkspaceConfig()
@@ -60,7 +73,13 @@ func (ws *Workspace) init() error {
 
 	ws.Context = ctx
 	ws.Components = make(map[string]*Component)
+	ws.ComponentNames = make([]string, 0, len(ws.Config.Components))
 	for compName := range ws.Config.Components {
+		ws.ComponentNames = append(ws.ComponentNames, compName)
+	}
+	sort.Strings(ws.ComponentNames)
+
+	for _, compName := range ws.ComponentNames {
 		compCfg, _ := ws.Config.Components[compName]
 		ws.Components[compName] = NewComponent(compName, &compCfg, ws)
 	}
@@ -69,8 +88,15 @@ func (ws *Workspace) init() error {
 		ws.Aliases[name] = realName
 	}
 
-	for _, comp := range ws.Components {
-		err := comp.init()
+	for _, compName := range ws.ComponentNames {
+		compCfg := ws.Config.Components[compName]
+		if compCfg.Alias != "" {
+			ws.Aliases[compCfg.Alias] = compName
+		}
+	}
+
+	for _, compName := range ws.ComponentNames {
+		err := ws.Components[compName].init()
 		if err != nil {
 			return err
 		}
@@ -79,7 +105,14 @@ func (ws *Workspace) init() error {
 	return nil
 }
 
-func (ws *Workspace) checkVersion() error {
+func (ws *Workspace) checkVersion(hc *HomeConfig, canSwitch bool) error {
+	if ws.Config.ElcVersion != "" {
+		if !canSwitch {
+			return ws.checkPinnedVersion()
+		}
+		return ws.switchVersion(hc)
+	}
+
 	if ws.Config.ElcMinVersion == "" {
 		return nil
 	}
@@ -100,20 +133,101 @@ func (ws *Workspace) checkVersion() error {
 }
 
 func (ws *Workspace) createContext() (*Context, error) {
-	ctx := make(Context, 0)
+	resolver := newVarResolver(ws.lookupNamespace)
+
+	resolver.addLiteral("WORKSPACE_PATH", strings.TrimRight(ws.ConfigPath, "/"))
+	resolver.addLiteral("WORKSPACE_NAME", ws.Config.Name)
+	// host variables can be overridden by workspace, eg. USER_ID: ${USER_ID:-1000}
+	resolver.addContext(hostVariables())
+
+	builtins, err := resolver.context()
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := NewSecretStore(ws.Config.Secrets, &builtins)
+	if err != nil {
+		return nil, err
+	}
+	ws.Secrets = secrets
 
-	ctx = ctx.add("WORKSPACE_PATH", strings.TrimRight(ws.ConfigPath, "/"))
-	ctx = ctx.add("WORKSPACE_NAME", ws.Config.Name)
+	envValues, err := loadEnvFiles(ws.Config.EnvFiles, ws.ConfigPath, resolver.RenderString)
+	if err != nil {
+		return nil, err
+	}
+	resolver.addContext(envValues)
+	resolver.addContext(passthroughEnv(ws.Config.EnvPassthrough))
 
 	for _, pair := range ws.Config.Variables {
-		value, err := substVars(pair.Value.(string), &ctx)
+		resolver.add(pair.Key.(string), pair.Value.(string))
+	}
+
+	ctx, err := resolver.context()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ctx, nil
+}
+
+// hostVariables returns built-in variables of host user and platform, values which can not be detected are skipped.
+func hostVariables() Context {
+	ctx := make(Context, 0)
+	if uid := Pc.Getuid(); uid >= 0 {
+		ctx = ctx.add("USER_ID", strconv.Itoa(uid))
+	}
+	if gid := Pc.Getgid(); gid >= 0 {
+		ctx = ctx.add("GROUP_ID", strconv.Itoa(gid))
+	}
+	if userName, err := Pc.Username(); err == nil && userName != "" {
+		ctx = ctx.add("USER_NAME", userName)
+	}
+	if homeDir, err := Pc.HomeDir(); err == nil && homeDir != "" {
+		ctx = ctx.add("HOME", homeDir)
+	}
+	ctx = ctx.add("HOST_OS", runtime.GOOS)
+	ctx = ctx.add("HOST_ARCH", runtime.GOARCH)
+
+	return ctx
+}
+
+// passthroughEnv takes allowed variables from host environment, unset ones are skipped.
+func passthroughEnv(names []string) Context {
+	ctx := make(Context, 0)
+	for _, name := range names {
+		value, found := Pc.LookupEnv(name)
+		if found {
+			ctx = ctx.add(name, value)
+		}
+	}
+
+	return ctx
+}
+
+// lookupNamespace resolves references like ${env:HOME} and ${secret:NAME}, which are not stored in context.
+func (ws *Workspace) lookupNamespace(name string) (string, bool, error) {
+	if strings.HasPrefix(name, "env:") {
+		value, found := Pc.LookupEnv(strings.TrimPrefix(name, "env:"))
+		return value, found, nil
+	}
+
+	if strings.HasPrefix(name, "secret:") {
+		value, err := ws.Secrets.Get(strings.TrimPrefix(name, "secret:"))
 		if err != nil {
-			return nil, err
+			return "", false, err
 		}
-		ctx = ctx.add(pair.Key.(string), value)
+		return value, true, nil
 	}
 
-	return &ctx, nil
+	return "", false, nil
+}
+
+// lookup is used for component variables: namespaces first, then already computed workspace variables.
+func (ws *Workspace) lookup(name string) (string, bool, error) {
+	if strings.Contains(name, ":") {
+		return ws.lookupNamespace(name)
+	}
+
+	return ws.Context.lookup(name)
 }
 
 func (ws *Workspace) ComponentByName(name string) (*Component, error) {
@@ -128,60 +242,47 @@ func (ws *Workspace) ComponentByName(name string) (*Component, error) {
 	return comp, nil
 }
 
+// ComponentByPath returns component whose SVC_PATH contains current directory.
+// For nested components the most specific one, with the longest path, is chosen. Templates are skipped.
 func (ws *Workspace) ComponentByPath() (*Component, error) {
-	for _, comp := range ws.Components {
-		compPath, found := comp.Context.find("SVC_PATH")
-		if found {
-			if strings.HasPrefix(compPath+"/", ws.Cwd+"/") {
-				return comp, nil
-			}
+	var found *Component
+	foundPath := ""
+	for _, name := range ws.ComponentNames {
+		comp := ws.Components[name]
+		if comp.Config.IsTemplate {
+			continue
 		}
-	}
-	return nil, errors.New(fmt.Sprintf("you are not in component folder"))
-}
-
-func (ws *Workspace) ComponentNameByPath() (string, error) {
-	for name, comp := range ws.Components {
-		compPath, found := comp.Context.find("SVC_PATH")
-		if found {
-			if strings.HasPrefix(compPath+"/", ws.Cwd+"/") {
-				return name, nil
-			}
+		compPath, ok := comp.Context.find("SVC_PATH")
+		if !ok || compPath == "" {
+			continue
+		}
+		compPath = strings.TrimRight(compPath, "/")
+		if strings.HasPrefix(ws.Cwd+"/", compPath+"/") && len(compPath) > len(foundPath) {
+			found = comp
+			foundPath = compPath
 		}
 	}
-	return "", errors.New(fmt.Sprintf("you are not in component folder"))
-}
 
-func (ws *Workspace) GetComponentNames() []string {
-	result := make([]string, 0)
-	for name, comp := range ws.Components {
-		if !comp.Config.IsTemplate && comp.Config.HostedIn == "" {
-			result = append(result, name)
-		}
+	if found == nil {
+		return nil, errors.New(fmt.Sprintf("you are not in component folder"))
 	}
 
-	return result
+	return found, nil
 }
 
-func (ws *Workspace) FindComponentNamesByTag(tag string) []string {
-	result := make([]string, 0)
-	for name, comp := range ws.Components {
-		if !comp.Config.IsTemplate {
-			for _, compTag := range comp.Config.Tags {
-				if compTag == tag {
-					result = append(result, name)
-				}
-			}
-		}
+func (ws *Workspace) ComponentNameByPath() (string, error) {
+	comp, err := ws.ComponentByPath()
+	if err != nil {
+		return "", err
 	}
 
-	return result
+	return comp.Name, nil
 }
 
-func (ws *Workspace) GetComponentNamesList() []string {
+func (ws *Workspace) GetComponentNames() []string {
 	result := make([]string, 0)
-	for name, comp := range ws.Components {
-		if !comp.Config.IsTemplate {
+	for _, name := range ws.ComponentNames {
+		if ws.Components[name].Config.Type() == ComponentTypeService {
 			result = append(result, name)
 		}
 	}
@@ -5,10 +5,15 @@ import (
 )
 
 type WorkspaceConfig struct {
-	Name          string                     `yaml:"name"`
-	ElcMinVersion string                     `yaml:"elc_min_version"`
-	Components    map[string]ComponentConfig `yaml:"components"`
-	Variables     yaml.MapSlice              `yaml:"variables"`
+	Name           string                     `yaml:"name"`
+	ElcMinVersion  string                     `yaml:"elc_min_version"`
+	ElcVersion     string                     `yaml:"elc_version"`
+	Components     map[string]ComponentConfig `yaml:"components"`
+	Variables      yaml.MapSlice              `yaml:"variables"`
+	EnvFiles       []string                   `yaml:"env_files"`
+	EnvPassthrough []string                   `yaml:"env_passthrough"`
+	Secrets        []SecretSourceConfig       `yaml:"secrets"`
+	Networks       map[string]NetworkConfig   `yaml:"networks"`
 
 	// deprecated
 	Aliases map[string]string `yaml:"aliases"`
@@ -24,6 +29,7 @@ func NewWorkspaceConfig() *WorkspaceConfig {
 	return &WorkspaceConfig{
 		Aliases:    make(map[string]string, 0),
 		Components: make(map[string]ComponentConfig, 0),
+		Networks:   make(map[string]NetworkConfig, 0),
 		Templates:  make(map[string]ComponentConfig, 0),
 		Services:   make(map[string]ComponentConfig, 0),
 		Modules:    make(map[string]ComponentConfig, 0),
@@ -31,18 +37,23 @@ func NewWorkspaceConfig() *WorkspaceConfig {
 }
 
 func (wsc *WorkspaceConfig) normalize() {
+	wsc.Variables = stringifyMapSlice(wsc.Variables)
+
 	for k, v := range wsc.Templates {
 		v.IsTemplate = true
+		v.Variables = stringifyMapSlice(v.Variables)
 		wsc.Components[k] = v
 	}
 	wsc.Templates = nil
 
 	for k, v := range wsc.Services {
+		v.Variables = stringifyMapSlice(v.Variables)
 		wsc.Components[k] = v
 	}
 	wsc.Services = nil
 
 	for k, v := range wsc.Modules {
+		v.Variables = stringifyMapSlice(v.Variables)
 		wsc.Components[k] = v
 	}
 	wsc.Modules = nil
@@ -57,6 +68,10 @@ func (wsc WorkspaceConfig) merge(wsc2 WorkspaceConfig) WorkspaceConfig {
 		}
 	}
 
+	for name, network := range wsc2.Networks {
+		wsc.Networks[name] = network
+	}
+
 	for alias, ccName := range wsc2.Aliases {
 		wsc.Aliases[alias] = ccName
 	}
@@ -67,7 +82,14 @@ func (wsc WorkspaceConfig) merge(wsc2 WorkspaceConfig) WorkspaceConfig {
 		}
 	}
 
-	wsc.Variables = append(wsc2.Variables, wsc.Variables...)
+	if wsc2.ElcVersion != "" {
+		wsc.ElcVersion = wsc2.ElcVersion
+	}
+
+	wsc.Variables = append(wsc.Variables, wsc2.Variables...)
+	wsc.EnvFiles = append(wsc.EnvFiles, wsc2.EnvFiles...)
+	wsc.EnvPassthrough = append(wsc.EnvPassthrough, wsc2.EnvPassthrough...)
+	wsc.Secrets = append(wsc2.Secrets, wsc.Secrets...)
 
 	return wsc
 }
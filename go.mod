@@ -7,11 +7,11 @@ require (
 	github.com/hashicorp/go-version v1.4.0
 	github.com/mattn/go-isatty v0.0.14
 	github.com/spf13/cobra v1.5.0
+	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
 	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 )